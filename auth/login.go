@@ -44,11 +44,11 @@ func Login(email, password string) error {
 
 	// DEBUG MODE: Use localhost API
 	apiURL := "https://api.vyx.network"
-	if config.GlobalConfig != nil && config.GlobalConfig.DebugMode {
+	if config.GetDebugMode() {
 		apiURL = "http://127.0.0.1:8080"
 	}
 
-	resp, err := http.Post(apiURL+"/api/auth/login", "application/json", bytes.NewBuffer(body))
+	resp, err := config.NewHTTPClient(config.HTTPTimeoutDefault).Post(apiURL+config.GetAPIPaths().Login, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
@@ -65,11 +65,7 @@ func Login(email, password string) error {
 	}
 
 	// Save to config
-	config.GlobalConfig.APIToken = authResp.Token
-	config.GlobalConfig.UserID = authResp.User.ID
-	config.GlobalConfig.Email = authResp.User.Email
-
-	return config.SaveConfig(config.GlobalConfig)
+	return config.SetCredentials(authResp.Token, authResp.User.ID, authResp.User.Email)
 }
 
 // Register creates a new account
@@ -86,11 +82,11 @@ func Register(email, password string) error {
 
 	// DEBUG MODE: Use localhost API
 	apiURL := "https://api.vyx.network"
-	if config.GlobalConfig != nil && config.GlobalConfig.DebugMode {
+	if config.GetDebugMode() {
 		apiURL = "http://127.0.0.1:8080"
 	}
 
-	resp, err := http.Post(apiURL+"/api/auth/register", "application/json", bytes.NewBuffer(body))
+	resp, err := config.NewHTTPClient(config.HTTPTimeoutDefault).Post(apiURL+config.GetAPIPaths().Register, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
@@ -107,11 +103,7 @@ func Register(email, password string) error {
 	}
 
 	// Save to config
-	config.GlobalConfig.APIToken = authResp.Token
-	config.GlobalConfig.UserID = authResp.User.ID
-	config.GlobalConfig.Email = authResp.User.Email
-
-	return config.SaveConfig(config.GlobalConfig)
+	return config.SetCredentials(authResp.Token, authResp.User.ID, authResp.User.Email)
 }
 
 // Logout clears credentials from both memory and secure storage
@@ -122,9 +114,5 @@ func Logout() error {
 	}
 
 	// Clear user data from config
-	config.GlobalConfig.APIToken = ""
-	config.GlobalConfig.UserID = ""
-	config.GlobalConfig.Email = ""
-
-	return config.SaveConfig(config.GlobalConfig)
+	return config.SetCredentials("", "", "")
 }
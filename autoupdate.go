@@ -1,8 +1,16 @@
+//go:build !noupdate
+
 package main
 
 import (
+	"client/config"
 	"client/logger"
+	"client/platform"
+	"client/version"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +18,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,24 +26,54 @@ import (
 )
 
 type GitHubRelease struct {
-	TagName string `json:"tag_name"`
-	Assets  []struct {
-		Name               string `json:"name"`
-		BrowserDownloadURL string `json:"browser_download_url"`
-	} `json:"assets"`
+	TagName string        `json:"tag_name"`
+	Assets  []GitHubAsset `json:"assets"`
 }
 
-const url = "https://api.github.com/repos/Vyx-Network/Vyx-Client/releases/latest"
+type GitHubAsset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
 
-func AutoUpdate() error {
-	logger.Info("Checking for updates (current version: %s)...", VERSION)
+// checksumAssetNames are release assets listing "<sha256>  <file>" lines, checked in order
+var checksumAssetNames = []string{"checksums.txt", "SHA256SUMS"}
+
+// updateDiskHeadroom is extra free space required beyond the asset size (update script, logs, filesystem slack)
+const updateDiskHeadroom = 10 << 20
+
+// defaultRateLimitBackoff is used when a rate-limited response carries no reset hint
+const defaultRateLimitBackoff = 1 * time.Hour
 
-	client := http.Client{
-		Timeout: 10 * time.Second,
+// rateLimitError means the update server (GitHub) rate-limited us until resetAt
+type rateLimitError struct {
+	resetAt time.Time
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("update check rate limited until %s", e.resetAt.Format(time.RFC3339))
+}
+
+func AutoUpdate() error {
+	// Don't spend another request while a previous run is still rate limited
+	if resetAt := loadUpdateBackoff(); time.Now().Before(resetAt) {
+		logger.Info("Skipping update check until %s (update server rate limit)", resetAt.Format("15:04"))
+		return nil
 	}
 
+	logger.Info("Checking for updates (current version: %s)...", version.Version)
+
+	client := config.NewHTTPClient(config.HTTPTimeoutDefault)
+
 	release, hasUpdate, err := checkForUpdate(client)
 	if err != nil {
+		var rateErr *rateLimitError
+		if errors.As(err, &rateErr) {
+			// Common when many nodes share one IP (NAT) - expected, not worth an error
+			logger.Info("Update server rate limit reached, next update check after %s", rateErr.resetAt.Format("15:04"))
+			saveUpdateBackoff(rateErr.resetAt)
+			return nil
+		}
 		if strings.Contains(err.Error(), "404") {
 			logger.Info("No releases available yet")
 			return nil // No release yet
@@ -43,19 +82,33 @@ func AutoUpdate() error {
 	}
 
 	if !hasUpdate {
-		logger.Info("You are running the latest version (%s)", VERSION)
+		logger.Info("You are running the latest version (%s)", version.Version)
 		return nil
 	}
 
-	logger.Info("Update available: %s → %s", VERSION, release.TagName)
+	logger.Info("Update available: %s → %s", version.Version, release.TagName)
 
-	assetURL, err := findAssetForPlatform(release)
+	asset, err := findAssetForPlatform(release)
 	if err != nil {
 		return fmt.Errorf("finding asset url: %w", err)
 	}
 
-	logger.Info("Downloading update from: %s", assetURL)
-	assetData, err := downloadUpdate(client, assetURL)
+	// Fail before downloading rather than halfway through replacing the binary
+	if asset.Size > 0 {
+		if err := checkUpdateDiskSpace(uint64(asset.Size)); err != nil {
+			return err
+		}
+	}
+
+	// SECURITY: a published checksum file that can't be used means the download can't be verified - don't install it
+	checksum, err := fetchAssetChecksum(client, release, asset.Name)
+	if err != nil {
+		return fmt.Errorf("getting release checksum: %w", err)
+	}
+
+	// The binary is far larger than the release metadata, give it a download-sized timeout
+	downloadClient := config.NewHTTPClient(config.HTTPTimeoutDownload)
+	assetData, err := downloadWithMirrors(downloadClient, release.TagName, asset, checksum)
 	if err != nil {
 		return fmt.Errorf("downloading update: %w", err)
 	}
@@ -70,60 +123,198 @@ func AutoUpdate() error {
 	return nil
 }
 
-func checkForUpdate(client http.Client) (*GitHubRelease, bool, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func checkForUpdate(client *http.Client) (*GitHubRelease, bool, error) {
+	// Mirrors must serve the same JSON as GitHub's "latest release" API (tag_name, assets[].name/size/browser_download_url)
+	req, err := http.NewRequest("GET", config.GetUpdateURL(), nil)
 
 	if err != nil {
 		return nil, false, fmt.Errorf("creating request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", "Vyx-updater/1.0")
-
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, false, fmt.Errorf("fetching release info: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		if resetAt, limited := rateLimitReset(resp); limited {
+			return nil, false, &rateLimitError{resetAt: resetAt}
+		}
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, false, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+		return nil, false, fmt.Errorf("update server returned status %d", resp.StatusCode)
 	}
 
 	var release GitHubRelease
 	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
 		return nil, false, fmt.Errorf("decoding release info: %w", err)
 	}
-	hasUpdate := semver.Compare(release.TagName, VERSION) == +1
+	hasUpdate := semver.Compare(release.TagName, version.Version) == +1
 
 	return &release, hasUpdate, nil
 }
 
-func findAssetForPlatform(release *GitHubRelease) (string, error) {
-	var assetURL string
-	for _, asset := range release.Assets {
-		assetName := strings.ToLower(asset.Name)
+// rateLimitReset reports whether a 403/429 response is a rate limit and when it lifts
+// GitHub sends X-RateLimit-Remaining: 0 with X-RateLimit-Reset (unix seconds), or Retry-After for secondary limits
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && retryAfter > 0 {
+		return time.Now().Add(time.Duration(retryAfter) * time.Second), true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return time.Now().Add(defaultRateLimitBackoff), true
+		}
+		return time.Time{}, false // A plain 403 (e.g. blocked repo) is a real error
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if resetAt := time.Unix(reset, 0); resetAt.After(time.Now()) {
+			return resetAt, true
+		}
+	}
+	return time.Now().Add(defaultRateLimitBackoff), true
+}
+
+// updateBackoffPath stores the rate-limit reset time so restarts don't re-hit the limit
+func updateBackoffPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".vyx", "update_backoff")
+}
+
+// loadUpdateBackoff returns when update checks may resume (zero if not rate limited)
+func loadUpdateBackoff() time.Time {
+	data, err := os.ReadFile(updateBackoffPath())
+	if err != nil {
+		return time.Time{}
+	}
+	reset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(reset, 0)
+}
+
+// saveUpdateBackoff records when update checks may resume
+func saveUpdateBackoff(resetAt time.Time) {
+	path := updateBackoffPath()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(strconv.FormatInt(resetAt.Unix(), 10)), 0644); err != nil {
+		logger.Warn("could not save update backoff: %v", err)
+	}
+}
+
+func findAssetForPlatform(release *GitHubRelease) (*GitHubAsset, error) {
+	for i := range release.Assets {
+		assetName := strings.ToLower(release.Assets[i].Name)
 
 		if strings.Contains(assetName, runtime.GOOS+"-"+runtime.GOARCH) {
-			assetURL = asset.BrowserDownloadURL
-			break
+			return &release.Assets[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no suitable asset found for %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// fetchAssetChecksum returns the expected SHA-256 (hex) of assetName from the release's checksum file
+// Returns "" with no error only if the release doesn't publish checksums; an unusable checksum file is an error
+func fetchAssetChecksum(client *http.Client, release *GitHubRelease, assetName string) (string, error) {
+	for _, name := range checksumAssetNames {
+		for _, asset := range release.Assets {
+			if asset.Name != name {
+				continue
+			}
+			data, err := downloadUpdate(client, asset.BrowserDownloadURL)
+			if err != nil {
+				return "", fmt.Errorf("downloading %s: %w", name, err)
+			}
+			for _, line := range strings.Split(string(data), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == assetName {
+					return strings.ToLower(fields[0]), nil
+				}
+			}
+			return "", fmt.Errorf("%s has no entry for %s", name, assetName)
+		}
+	}
+	return "", nil
+}
+
+// downloadWithMirrors downloads the asset from GitHub, then from each update_mirrors entry in order
+// Mirrors serve "<mirror>/<tag>/<asset name>" and are only used when a release checksum can verify them
+func downloadWithMirrors(client *http.Client, tag string, asset *GitHubAsset, checksum string) ([]byte, error) {
+	sources := []string{asset.BrowserDownloadURL}
+	if mirrors := config.GetUpdateMirrors(); len(mirrors) > 0 {
+		if checksum == "" {
+			// SECURITY: without a checksum a mirror could serve anything - stick to the release URL
+			logger.Warn("release has no checksum, not using update mirrors")
+		} else {
+			for _, mirror := range mirrors {
+				sources = append(sources, strings.TrimRight(mirror, "/")+"/"+tag+"/"+asset.Name)
+			}
+		}
+	}
+
+	var lastErr error
+	for _, source := range sources {
+		logger.Info("Downloading update from: %s", source)
+		data, err := downloadUpdate(client, source)
+		if err == nil && checksum != "" {
+			err = verifyChecksum(data, checksum)
 		}
+		if err == nil {
+			return data, nil
+		}
+		logger.Warn("download from %s failed: %v", source, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// verifyChecksum checks data against an expected hex SHA-256
+func verifyChecksum(data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", actual, expected)
+	}
+	return nil
+}
+
+// checkUpdateDiskSpace verifies every directory the update writes to can hold size bytes
+// Unix writes next to the current executable; Windows stages in the temp dir, then moves it next to the executable
+func checkUpdateDiskSpace(size uint64) error {
+	currentExe, err := platform.ExecutablePath()
+	if err != nil {
+		return fmt.Errorf("getting current executable path: %w", err)
 	}
 
-	if assetURL == "" {
-		return "", fmt.Errorf("no suitable asset found for %s/%s", runtime.GOOS, runtime.GOARCH)
+	dirs := []string{filepath.Dir(currentExe)}
+	if runtime.GOOS == "windows" {
+		dirs = append(dirs, os.TempDir())
 	}
 
-	return assetURL, nil
+	required := size + updateDiskHeadroom
+	for _, dir := range dirs {
+		free, err := platform.FreeDiskSpace(dir)
+		if err != nil {
+			// Can't tell - don't block the update on a failed query
+			logger.Warn("could not check free disk space in %s: %v", dir, err)
+			continue
+		}
+		if free < required {
+			return fmt.Errorf("not enough disk space in %s to install update: need %d MB, %d MB free",
+				dir, required>>20, free>>20)
+		}
+	}
+	return nil
 }
 
-func downloadUpdate(client http.Client, url string) ([]byte, error) {
+func downloadUpdate(client *http.Client, url string) ([]byte, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating download request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", "Vyx-updater/1.0")
-
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("downloading asset: %w", err)
@@ -138,11 +329,17 @@ func downloadUpdate(client http.Client, url string) ([]byte, error) {
 }
 
 func replaceExecutable(newExecutable []byte, newVersion string) error {
-	currentExe, err := os.Executable()
+	// Resolve symlinks so the update replaces the real binary, not the link pointing at it
+	currentExe, err := platform.ExecutablePath()
 	if err != nil {
 		return fmt.Errorf("getting current executable path: %w", err)
 	}
 
+	// Re-check with the actual size (the release may not list one) before touching the current binary
+	if err := checkUpdateDiskSpace(uint64(len(newExecutable))); err != nil {
+		return err
+	}
+
 	// On Windows, we can't replace a running executable
 	// Create a batch script to replace it after exit
 	if runtime.GOOS == "windows" {
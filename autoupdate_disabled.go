@@ -0,0 +1,11 @@
+//go:build noupdate
+
+package main
+
+import "client/logger"
+
+// AutoUpdate is compiled out of packaged builds (-tags noupdate); updates come from the package manager
+func AutoUpdate() error {
+	logger.Info("Self-update is disabled in this build, use your package manager to update")
+	return nil
+}
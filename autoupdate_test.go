@@ -0,0 +1,155 @@
+//go:build !noupdate
+
+package main
+
+import (
+	"client/config"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testAssetName = "vyx-linux-amd64"
+
+// testChecksum returns the hex SHA-256 of data
+func testChecksum(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// serveFiles serves files by path; other paths get a 404
+func serveFiles(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchAssetChecksum(t *testing.T) {
+	good := testChecksum("binary")
+	srv := serveFiles(t, map[string]string{
+		"/checksums.txt": good + "  " + testAssetName + "\n",
+		"/SHA256SUMS":    good + " *" + testAssetName + "\n",
+		"/other.txt":     good + "  vyx-windows-amd64.exe\n",
+	})
+
+	tests := []struct {
+		name    string
+		assets  map[string]string // Asset name -> download path
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "no checksum asset",
+			assets: map[string]string{testAssetName: "/binary"},
+		},
+		{
+			name:   "entry found",
+			assets: map[string]string{"checksums.txt": "/checksums.txt"},
+			want:   good,
+		},
+		{
+			name:   "binary mode entry",
+			assets: map[string]string{"SHA256SUMS": "/SHA256SUMS"},
+			want:   good,
+		},
+		{
+			name:    "entry missing",
+			assets:  map[string]string{"checksums.txt": "/other.txt"},
+			wantErr: "no entry for " + testAssetName,
+		},
+		{
+			name:    "checksum file unavailable",
+			assets:  map[string]string{"checksums.txt": "/missing"},
+			wantErr: "downloading checksums.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := &GitHubRelease{TagName: "v1.0.0"}
+			for name, path := range tt.assets {
+				release.Assets = append(release.Assets, GitHubAsset{Name: name, BrowserDownloadURL: srv.URL + path})
+			}
+
+			got, err := fetchAssetChecksum(srv.Client(), release, testAssetName)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchAssetChecksum() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchAssetChecksum() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("fetchAssetChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadWithMirrors(t *testing.T) {
+	release := serveFiles(t, map[string]string{"/release/" + testAssetName: "tampered"})
+	mirror := serveFiles(t, map[string]string{"/v1.0.0/" + testAssetName: "binary"})
+	badMirror := serveFiles(t, map[string]string{"/v1.0.0/" + testAssetName: "also tampered"})
+	asset := &GitHubAsset{Name: testAssetName, BrowserDownloadURL: release.URL + "/release/" + testAssetName}
+
+	tests := []struct {
+		name     string
+		mirrors  []string
+		checksum string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "checksum mismatch",
+			checksum: testChecksum("binary"),
+			wantErr:  "checksum mismatch",
+		},
+		{
+			name:     "mirror used after mismatch",
+			mirrors:  []string{badMirror.URL, mirror.URL + "/"},
+			checksum: testChecksum("binary"),
+			want:     "binary",
+		},
+		{
+			name:    "mirrors skipped without checksum",
+			mirrors: []string{mirror.URL},
+			want:    "tampered",
+		},
+	}
+
+	savedConfig := config.Current()
+	t.Cleanup(func() { config.SetCurrent(savedConfig) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.SetCurrent(&config.Config{UpdateMirrors: tt.mirrors})
+
+			got, err := downloadWithMirrors(release.Client(), "v1.0.0", asset, tt.checksum)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("downloadWithMirrors() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("downloadWithMirrors() unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("downloadWithMirrors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,29 +2,213 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Config struct {
+	// Version is the config schema version, used to migrate older files on load
+	Version   int    `json:"version"`
 	ServerURL string `json:"server_url"`
 	// SECURITY: APIToken is stored in OS keyring, not in JSON file
 	APIToken string `json:"-"` // json:"-" excludes from JSON serialization
 	UserID   string `json:"user_id,omitempty"`
 	Email    string `json:"email,omitempty"`
 	// PRIVACY: VerboseLogging enables detailed connection logs (default: false)
-	// When false, destination addresses are not logged to protect proxy user privacy
+	// When false, destination addresses, emails and user IDs are not logged to protect user privacy
 	VerboseLogging bool `json:"verbose_logging,omitempty"`
+	// LogLevel is the minimum severity logged: "info" (default), "warn" or "error"
+	// The -quiet flag overrides it with "warn"
+	LogLevel string `json:"log_level,omitempty"`
+	// LogTimestamps is the log line time format: "local" (default), "utc", "iso" or "iso-utc" (ISO 8601)
+	// The -log-utc flag overrides it with "iso-utc"
+	LogTimestamps string `json:"log_timestamps,omitempty"`
 	// AutoStart controls whether the app starts on system boot (default: true)
 	AutoStart *bool `json:"auto_start,omitempty"` // Use pointer to distinguish between false and unset
 	// DEBUG: DebugMode enables local development mode (connects to 127.0.0.1)
 	// API server at 127.0.0.1:8080, QUIC server at 127.0.0.1:8443
 	DebugMode bool `json:"debug_mode,omitempty"`
+	// HealthAddr enables an HTTP /healthz endpoint on this address (e.g. "127.0.0.1:9090")
+	// Empty disables the endpoint (default)
+	HealthAddr string `json:"health_addr,omitempty"`
+	// FallbackDNS lists resolvers tried in order when system DNS fails (default: 8.8.8.8)
+	// Entries may be "ip" or "ip:port"; port 53 is assumed when omitted
+	FallbackDNS []string `json:"fallback_dns,omitempty"`
+	// DisableFallbackDNS turns off the fallback resolvers entirely (system DNS only)
+	DisableFallbackDNS bool `json:"disable_fallback_dns,omitempty"`
+	// BindAddr is the local source IP relayed connections egress from (e.g. a dedicated sharing interface)
+	// Empty lets the OS pick (default); the server connection itself is not affected
+	BindAddr string `json:"bind_addr,omitempty"`
+	// DataChannelBuffer is the per-connection queue capacity for data from the server (default: 10000)
+	// Lower values reduce memory use on small hosts at the cost of throughput
+	DataChannelBuffer int `json:"data_channel_buffer,omitempty"`
+	// PRIVACY: Telemetry opts in to anonymous usage stats (default: false)
+	// Only aggregate counters are sent - never destinations, user IDs or emails
+	Telemetry bool `json:"telemetry,omitempty"`
+	// AuthTimeoutSeconds is how long to wait for the browser login callback (default: 120)
+	// Raise it if completing login (e.g. with 2FA) regularly takes longer
+	AuthTimeoutSeconds int `json:"auth_timeout_seconds,omitempty"`
+	// AllowedPorts restricts relaying to these destination ports (e.g. [80, 443])
+	// Empty allows all ports (default)
+	AllowedPorts []int `json:"allowed_ports,omitempty"`
+	// ConnectRateLimit caps new connections per second (token bucket); 0 = unlimited (default)
+	// ConnectBurst is how many connects may arrive at once before the rate applies (default: rate)
+	ConnectRateLimit float64 `json:"connect_rate_limit,omitempty"`
+	ConnectBurst     int     `json:"connect_burst,omitempty"`
+	// MaxConnsPerHost caps concurrent relayed connections to one destination host; 0 = unlimited (default)
+	// Stops the node from being used to flood a single target
+	MaxConnsPerHost int `json:"max_conns_per_host,omitempty"`
+	// LastServer is the last server we authenticated with; reconnects prefer it while it stays healthy
+	// Managed by the client, not meant to be edited by hand
+	LastServer string `json:"last_server,omitempty"`
+	// RequireTLS13 refuses server connections that can't negotiate TLS 1.3 (default: false, TLS 1.2 allowed)
+	RequireTLS13 bool `json:"require_tls13,omitempty"`
+	// MemoryProfile tunes buffer sizes: "default" for throughput, "low" for small hosts (e.g. 512MB VPS)
+	MemoryProfile string `json:"memory_profile,omitempty"`
+	// WorkerStreams is how many QUIC streams relay traffic (default: 1); more spreads work on high-capacity nodes
+	// Requires server support; extra streams are skipped over the TCP fallback
+	WorkerStreams int `json:"worker_streams,omitempty"`
+	// TCPFallback tunnels the server connection over TLS/TCP when UDP (QUIC) is blocked (default: false)
+	// Requires server support; TCPFallbackPort defaults to the QUIC port
+	TCPFallback     bool `json:"tcp_fallback,omitempty"`
+	TCPFallbackPort int  `json:"tcp_fallback_port,omitempty"`
+	// FirstRunCompleted is set once the first-launch login prompt has been shown
+	FirstRunCompleted bool `json:"first_run_completed,omitempty"`
+	// TokenValidationMinutes re-checks the stored token against the API this often while sharing
+	// 0 disables the check (default); a revoked token stops sharing and prompts re-login
+	TokenValidationMinutes int `json:"token_validation_minutes,omitempty"`
+	// HTTPProxy routes API and update requests through this proxy (http://, https:// or socks5://)
+	// Empty uses the HTTPS_PROXY/HTTP_PROXY environment variables (default); relayed traffic never uses it
+	HTTPProxy string `json:"http_proxy,omitempty"`
+	// UserAgentTag is appended to the User-Agent of API and update requests (e.g. "acme-fleet")
+	// Lets operators attribute a deployment's traffic in access logs; the client version is always included
+	UserAgentTag string `json:"user_agent_tag,omitempty"`
+	// UpdateURL is the release endpoint checked for updates (default: Vyx-Network/Vyx-Client on GitHub)
+	// Must return a GitHub "latest release" JSON document
+	UpdateURL string `json:"update_url,omitempty"`
+	// UpdateMirrors are base URLs tried in order when the GitHub download fails
+	// Each serves "<mirror>/<tag>/<asset name>"; only used when the release publishes checksums
+	UpdateMirrors []string `json:"update_mirrors,omitempty"`
+	// APIPaths overrides API routes for self-hosted or staging backends (default: Vyx routes)
+	APIPaths *APIPaths `json:"api_paths,omitempty"`
+	// ServerSelection tunes how discovered servers are scored (default: 60% load, 40% latency, skip above 90%)
+	ServerSelection *ServerSelection `json:"server_selection,omitempty"`
 }
 
-var GlobalConfig *Config
+// APIPaths are the API routes appended to the API base URL; empty fields use the defaults
+type APIPaths struct {
+	Servers   string `json:"servers,omitempty"`
+	Telemetry string `json:"telemetry,omitempty"`
+	Login     string `json:"login,omitempty"`
+	Register  string `json:"register,omitempty"`
+	Validate  string `json:"validate,omitempty"`
+}
+
+// DefaultAPIPaths are the routes served by the Vyx API
+var DefaultAPIPaths = APIPaths{
+	Servers:   "/api/servers",
+	Telemetry: "/api/telemetry",
+	Login:     "/api/auth/login",
+	Register:  "/api/auth/register",
+	Validate:  "/api/auth/validate",
+}
+
+// ServerSelection weighs server load against latency when picking a server
+// Weights are relative (0.6/0.4 equals 3/2); both zero means the defaults, one zero ignores that factor
+type ServerSelection struct {
+	LoadWeight    float64 `json:"load_weight,omitempty"`
+	LatencyWeight float64 `json:"latency_weight,omitempty"`
+	// OverloadPercent skips servers above this utilization unless all of them are (default: 90)
+	OverloadPercent float64 `json:"overload_percent,omitempty"`
+	// WaitWhenBusy waits and rediscovers when every server is above OverloadPercent
+	// (default: false, connect to the least loaded one anyway)
+	WaitWhenBusy bool `json:"wait_when_busy,omitempty"`
+}
+
+// DefaultServerSelection is the scoring used when server_selection is not set
+var DefaultServerSelection = ServerSelection{
+	LoadWeight:      0.6,
+	LatencyWeight:   0.4,
+	OverloadPercent: 90,
+}
+
+// DefaultFallbackDNS is used when no fallback resolvers are configured
+var DefaultFallbackDNS = []string{"8.8.8.8:53"}
+
+// Memory profiles selectable via memory_profile
+const (
+	MemoryProfileDefault = "default"
+	MemoryProfileLow     = "low"
+)
+
+// DefaultServerURL is used when server_url is missing or empty
+const DefaultServerURL = "proxy.vyx.network"
+
+// DefaultUpdateURL is the GitHub API endpoint for the latest Vyx release
+const DefaultUpdateURL = "https://api.github.com/repos/Vyx-Network/Vyx-Client/releases/latest"
+
+const (
+	// DefaultDataChannelBuffer is the per-connection queue capacity when unset
+	DefaultDataChannelBuffer = 10000
+	// MaxDataChannelBuffer caps the queue capacity to keep memory bounded
+	MaxDataChannelBuffer = 100000
+	// LowMemoryDataChannelBuffer is the queue capacity used by the "low" memory profile when unset
+	LowMemoryDataChannelBuffer = 1000
+
+	// MaxWorkerStreams caps worker_streams; beyond this the server, not the client, is the bottleneck
+	MaxWorkerStreams = 8
+
+	// DefaultAuthTimeout is how long to wait for the browser login callback when unset
+	DefaultAuthTimeout = 120 * time.Second
+	// MaxAuthTimeoutSeconds caps the login window so the callback server doesn't linger
+	MaxAuthTimeoutSeconds = 1800
+
+	// MinTokenValidationMinutes keeps token re-validation from hammering the API
+	MinTokenValidationMinutes = 5
+)
+
+var (
+	// current holds the settings in use; a stored Config is never modified, so readers need no lock
+	// Changes go through UpdateConfig, which swaps in an edited copy
+	current     atomic.Pointer[Config]
+	updateMutex sync.Mutex // Serializes UpdateConfig so concurrent changes aren't lost
+)
+
+// Current returns a snapshot of the settings in use (nil before LoadConfig); it must not be modified
+func Current() *Config {
+	return current.Load()
+}
+
+// SetCurrent makes config the settings in use; config must not be modified afterwards
+func SetCurrent(config *Config) {
+	current.Store(config)
+}
+
+// UpdateConfig applies change to a copy of the current settings and makes the copy current
+// The copy shares slices and pointers with the old settings: replace those fields, don't modify them
+func UpdateConfig(change func(config *Config)) (*Config, error) {
+	updateMutex.Lock()
+	defer updateMutex.Unlock()
+
+	old := current.Load()
+	if old == nil {
+		return nil, fmt.Errorf("config not initialized")
+	}
+	updated := *old
+	change(&updated)
+	current.Store(&updated)
+	return &updated, nil
+}
 
 // LoadConfig reads configuration from config.json and retrieves token from secure storage
 func LoadConfig() (*Config, error) {
@@ -32,12 +216,7 @@ func LoadConfig() (*Config, error) {
 
 	// Create default config if doesn't exist
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		defaultConfig := &Config{
-			ServerURL: "proxy.vyx.network",
-		}
-		SaveConfig(defaultConfig)
-		GlobalConfig = defaultConfig
-		return defaultConfig, nil
+		return createDefaultConfig(), nil
 	}
 
 	data, err := os.ReadFile(configPath)
@@ -47,7 +226,22 @@ func LoadConfig() (*Config, error) {
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+		// Hand-edited or corrupted file: keep a copy instead of silently losing settings
+		backupPath, backupErr := backupBrokenConfig(configPath)
+		if backupErr != nil {
+			return nil, fmt.Errorf("config file %s is invalid (%v) and could not be backed up: %w", configPath, err, backupErr)
+		}
+		log.Printf("ERROR: Config file %s is not valid JSON: %v", configPath, err)
+		log.Printf("ERROR: Your previous settings were saved to %s - fix and rename it back to restore them", backupPath)
+		return createDefaultConfig(), nil
+	}
+
+	applyEnvOverrides(&config)
+	validateConfig(&config)
+	if migrateConfig(&config) {
+		if err := SaveConfig(&config); err != nil {
+			log.Printf("Warning: Failed to save migrated config: %v", err)
+		}
 	}
 
 	// SECURITY MIGRATION: Check for legacy plaintext token in JSON
@@ -76,17 +270,24 @@ func LoadConfig() (*Config, error) {
 		token, err := storage.GetToken()
 		if err == nil {
 			config.APIToken = token
+			markTokenStored(token)
+		} else if errors.Is(err, ErrKeyringUnavailable) {
+			// Don't hang startup on a keyring prompt - continue logged out for now
+			log.Printf("Keyring unavailable, continuing without stored token: %v", err)
+			log.Println("Unlock your keyring and restart, or log in again to use the token for this session")
 		} else {
 			// Token not found in keyring - user needs to login again
-			log.Printf("No token found in secure storage for user %s", config.UserID)
+			log.Printf("No token found in secure storage for user %s", RedactPersonal(config.UserID))
 		}
 	}
 
-	GlobalConfig = &config
+	SetCurrent(&config)
 	return &config, nil
 }
 
 // SaveConfig writes configuration to config.json and stores token in secure storage
+// Returns ErrTokenNotPersisted (after writing the file) when the token couldn't be stored,
+// so login flows can tell the user the session won't survive a restart; later saves retry the keyring
 func SaveConfig(config *Config) error {
 	configPath := getConfigPath()
 
@@ -97,23 +298,223 @@ func SaveConfig(config *Config) error {
 	}
 
 	// SECURITY: Save token to secure storage (OS keyring) if present
-	if config.APIToken != "" && config.UserID != "" {
+	var tokenErr error
+	if config.APIToken != "" && config.UserID != "" && tokenNeedsSave(config.APIToken) {
 		storage := NewSecureStorage(config.UserID)
 		if err := storage.SaveToken(config.APIToken); err != nil {
+			// Token stays in memory for this session (it is never written to the JSON file)
 			log.Printf("Warning: Failed to save token to secure storage: %v", err)
+			tokenErr = fmt.Errorf("%w: %v", ErrTokenNotPersisted, err)
 			// Continue anyway to save other config data
+		} else {
+			markTokenStored(config.APIToken)
 		}
 	}
 
 	// Marshal config to JSON (APIToken excluded due to json:"-" tag)
-	data, err := json.MarshalIndent(config, "", "  ")
+	// Environment overrides are not persisted; config.json keeps its own values for those fields
+	data, err := json.MarshalIndent(withoutEnvOverrides(*config), "", "  ")
 	if err != nil {
 		return err
 	}
 
 	// SECURITY: Use 0600 permissions (read/write for owner only, not world-readable)
 	// Changed from 0644 to prevent other users from reading config file
-	return os.WriteFile(configPath, data, 0600)
+	if err := writeFileAtomic(configPath, data, 0600); err != nil {
+		return err
+	}
+	rememberSave(data)
+	return tokenErr
+}
+
+// saveSettings saves config after a settings change
+// A token the keyring still refuses was already reported at login, so it doesn't fail the change itself
+func saveSettings(config *Config) error {
+	if err := SaveConfig(config); err != nil && !errors.Is(err, ErrTokenNotPersisted) {
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data via a temp file in the same directory and a rename
+// A crash or a concurrent save leaves either the old or the new file, never a truncated one
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	// Flush to disk before the rename, or a power loss could still leave an empty file in place
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// createDefaultConfig writes a fresh default config and makes it the global config
+func createDefaultConfig() *Config {
+	defaultConfig := &Config{
+		Version:   CurrentConfigVersion,
+		ServerURL: DefaultServerURL,
+	}
+	applyEnvOverrides(defaultConfig)
+	validateConfig(defaultConfig)
+	SaveConfig(defaultConfig)
+	SetCurrent(defaultConfig)
+	return defaultConfig
+}
+
+// validateConfig fills in missing required fields and resets out-of-range values to their defaults
+func validateConfig(config *Config) {
+	validateConfigWith(config, log.Printf)
+}
+
+// validateConfigWith is validateConfig reporting each reset value through logf
+func validateConfigWith(config *Config, logf func(format string, v ...interface{})) {
+	config.ServerURL = strings.TrimSpace(config.ServerURL)
+	if config.ServerURL == "" {
+		logf("Warning: server_url is missing from config, using the default API")
+	} else if strings.ContainsAny(config.ServerURL, " \t") {
+		logf("Warning: server_url %q is not a valid address, using the default API", config.ServerURL)
+		config.ServerURL = ""
+	}
+	if config.DataChannelBuffer < 0 || config.DataChannelBuffer > MaxDataChannelBuffer {
+		logf("Warning: data_channel_buffer %d out of range (1-%d), using default %d",
+			config.DataChannelBuffer, MaxDataChannelBuffer, DefaultDataChannelBuffer)
+		config.DataChannelBuffer = 0
+	}
+	// Invalid ports are kept (they never match) so a bad list can't widen to "allow all"
+	for _, port := range config.AllowedPorts {
+		if port < 1 || port > 65535 {
+			logf("Warning: invalid port %d in allowed_ports will never match", port)
+		}
+	}
+	if config.MaxConnsPerHost < 0 {
+		logf("Warning: max_conns_per_host %d is negative, disabling the per-host limit", config.MaxConnsPerHost)
+		config.MaxConnsPerHost = 0
+	}
+	if config.ConnectRateLimit < 0 {
+		logf("Warning: connect_rate_limit %v is negative, disabling rate limit", config.ConnectRateLimit)
+		config.ConnectRateLimit = 0
+	}
+	if config.ConnectBurst < 0 {
+		logf("Warning: connect_burst %d is negative, using default", config.ConnectBurst)
+		config.ConnectBurst = 0
+	}
+	if config.APIPaths != nil {
+		for name, path := range map[string]*string{
+			"servers":   &config.APIPaths.Servers,
+			"telemetry": &config.APIPaths.Telemetry,
+			"login":     &config.APIPaths.Login,
+			"register":  &config.APIPaths.Register,
+			"validate":  &config.APIPaths.Validate,
+		} {
+			*path = strings.TrimSpace(*path)
+			if *path != "" && !strings.HasPrefix(*path, "/") {
+				logf("Warning: api_paths.%s %q must start with \"/\", using default", name, *path)
+				*path = ""
+			}
+		}
+	}
+	if sel := config.ServerSelection; sel != nil {
+		if sel.LoadWeight < 0 || sel.LatencyWeight < 0 {
+			logf("Warning: server_selection weights must not be negative, using defaults")
+			sel.LoadWeight, sel.LatencyWeight = 0, 0
+		}
+		if sel.OverloadPercent < 0 || sel.OverloadPercent > 100 {
+			logf("Warning: server_selection.overload_percent %v out of range (1-100), using default", sel.OverloadPercent)
+			sel.OverloadPercent = 0
+		}
+	}
+	config.MemoryProfile = strings.ToLower(strings.TrimSpace(config.MemoryProfile))
+	if config.MemoryProfile != "" && config.MemoryProfile != MemoryProfileDefault && config.MemoryProfile != MemoryProfileLow {
+		logf("Warning: unknown memory_profile %q, using %q", config.MemoryProfile, MemoryProfileDefault)
+		config.MemoryProfile = ""
+	}
+	config.LogLevel = strings.ToLower(strings.TrimSpace(config.LogLevel))
+	switch config.LogLevel {
+	case "", "info", "warn", "warning", "error":
+	default:
+		logf("Warning: unknown log_level %q, using \"info\"", config.LogLevel)
+		config.LogLevel = ""
+	}
+	config.BindAddr = strings.TrimSpace(config.BindAddr)
+	if config.BindAddr != "" {
+		if ip := net.ParseIP(config.BindAddr); ip == nil {
+			logf("Warning: bind_addr %q is not an IP address, ignoring it", config.BindAddr)
+			config.BindAddr = ""
+		} else if !isLocalIP(ip) {
+			// Kept rather than dropped: silently egressing elsewhere would defeat the isolation it asks for
+			logf("Warning: bind_addr %s is not assigned to this host, relayed connections will fail until it is", ip)
+		}
+	}
+	config.LogTimestamps = strings.ToLower(strings.TrimSpace(config.LogTimestamps))
+	switch config.LogTimestamps {
+	case "", "local", "utc", "iso", "iso-utc":
+	default:
+		logf("Warning: unknown log_timestamps %q, using \"local\"", config.LogTimestamps)
+		config.LogTimestamps = ""
+	}
+	config.HTTPProxy = strings.TrimSpace(config.HTTPProxy)
+	if config.HTTPProxy != "" && !isValidProxyURL(config.HTTPProxy) {
+		logf("Warning: http_proxy %q is not a valid http://, https:// or socks5:// URL, ignoring it", config.HTTPProxy)
+		config.HTTPProxy = ""
+	}
+	config.UserAgentTag = strings.TrimSpace(config.UserAgentTag)
+	if config.UserAgentTag != "" && !isValidUserAgentTag(config.UserAgentTag) {
+		logf("Warning: user_agent_tag %q must be up to %d printable characters without spaces, ignoring it",
+			config.UserAgentTag, maxUserAgentTagLen)
+		config.UserAgentTag = ""
+	}
+	config.UpdateURL = strings.TrimSpace(config.UpdateURL)
+	if config.UpdateURL != "" && !isValidUpdateURL(config.UpdateURL) {
+		logf("Warning: update_url %q is not a valid http(s) URL, using the default", config.UpdateURL)
+		config.UpdateURL = ""
+	}
+	mirrors := config.UpdateMirrors[:0]
+	for _, mirror := range config.UpdateMirrors {
+		mirror = strings.TrimSpace(mirror)
+		if !isValidUpdateURL(mirror) {
+			logf("Warning: ignoring invalid update mirror %q", mirror)
+			continue
+		}
+		mirrors = append(mirrors, mirror)
+	}
+	config.UpdateMirrors = mirrors
+	if config.WorkerStreams < 0 || config.WorkerStreams > MaxWorkerStreams {
+		logf("Warning: worker_streams %d out of range (1-%d), using 1", config.WorkerStreams, MaxWorkerStreams)
+		config.WorkerStreams = 0
+	}
+	if config.TCPFallbackPort < 0 || config.TCPFallbackPort > 65535 {
+		logf("Warning: tcp_fallback_port %d out of range (1-65535), using the QUIC port", config.TCPFallbackPort)
+		config.TCPFallbackPort = 0
+	}
+	if config.TokenValidationMinutes < 0 {
+		logf("Warning: token_validation_minutes %d is negative, disabling token validation", config.TokenValidationMinutes)
+		config.TokenValidationMinutes = 0
+	} else if config.TokenValidationMinutes > 0 && config.TokenValidationMinutes < MinTokenValidationMinutes {
+		logf("Warning: token_validation_minutes %d is below the minimum, using %d",
+			config.TokenValidationMinutes, MinTokenValidationMinutes)
+		config.TokenValidationMinutes = MinTokenValidationMinutes
+	}
+	if config.AuthTimeoutSeconds < 0 || config.AuthTimeoutSeconds > MaxAuthTimeoutSeconds {
+		logf("Warning: auth_timeout_seconds %d out of range (1-%d), using default %v",
+			config.AuthTimeoutSeconds, MaxAuthTimeoutSeconds, DefaultAuthTimeout)
+		config.AuthTimeoutSeconds = 0
+	}
 }
 
 // getConfigPath returns the path to config.json
@@ -124,51 +525,387 @@ func getConfigPath() string {
 
 // IsLoggedIn checks if user is authenticated by verifying token in secure storage
 func IsLoggedIn() bool {
-	if GlobalConfig == nil || GlobalConfig.UserID == "" {
+	cfg := Current()
+	if cfg == nil || cfg.UserID == "" {
 		return false
 	}
 
 	// Check in-memory token first (already loaded)
-	if GlobalConfig.APIToken != "" {
+	if cfg.APIToken != "" {
 		return true
 	}
 
 	// Check secure storage as fallback
-	storage := NewSecureStorage(GlobalConfig.UserID)
+	storage := NewSecureStorage(cfg.UserID)
 	return storage.HasToken()
 }
 
 // ClearAuthToken removes the authentication token from secure storage
 // This should be called during logout
 func ClearAuthToken() error {
-	if GlobalConfig == nil || GlobalConfig.UserID == "" {
+	cfg := Current()
+	if cfg == nil || cfg.UserID == "" {
 		return nil // Nothing to clear
 	}
 
-	storage := NewSecureStorage(GlobalConfig.UserID)
+	storage := NewSecureStorage(cfg.UserID)
 	if err := storage.DeleteToken(); err != nil {
 		return err
 	}
 
 	// Clear in-memory token as well
-	GlobalConfig.APIToken = ""
+	_, err := UpdateConfig(func(config *Config) { config.APIToken = "" })
+	return err
+}
+
+// SetCredentials makes token, userID and email the current login and saves them ("" for all logs out)
+// Returns ErrTokenNotPersisted when the keyring refused the token, like SaveConfig
+func SetCredentials(token, userID, email string) error {
+	updated, err := UpdateConfig(func(config *Config) {
+		config.APIToken = token
+		config.UserID = userID
+		config.Email = email
+	})
+	if err != nil {
+		return err
+	}
+	return SaveConfig(updated)
+}
+
+// ResetConfig removes the stored token and replaces config.json with defaults
+// Used to recover from a bad state (stale server URL, partial login)
+func ResetConfig() error {
+	if Current() == nil {
+		if _, err := LoadConfig(); err != nil {
+			log.Printf("Warning: Could not load existing config: %v", err)
+		}
+	}
+
+	// Remove keyring token first so a failed file reset doesn't leave credentials behind
+	if err := ClearAuthToken(); err != nil {
+		return fmt.Errorf("failed to remove token from secure storage: %w", err)
+	}
+
+	if err := os.Remove(getConfigPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove config file: %w", err)
+	}
+
+	createDefaultConfig()
+	log.Printf("Settings reset to defaults (%s)", getConfigPath())
 	return nil
 }
 
+// GetAPIPaths returns the API routes, with unset entries filled from DefaultAPIPaths
+func GetAPIPaths() APIPaths {
+	cfg := Current()
+	paths := DefaultAPIPaths
+	if cfg == nil || cfg.APIPaths == nil {
+		return paths
+	}
+
+	custom := cfg.APIPaths
+	if custom.Servers != "" {
+		paths.Servers = custom.Servers
+	}
+	if custom.Telemetry != "" {
+		paths.Telemetry = custom.Telemetry
+	}
+	if custom.Login != "" {
+		paths.Login = custom.Login
+	}
+	if custom.Register != "" {
+		paths.Register = custom.Register
+	}
+	if custom.Validate != "" {
+		paths.Validate = custom.Validate
+	}
+	return paths
+}
+
+// GetServerSelection returns the server scoring settings, with weights normalized to sum to 1
+func GetServerSelection() ServerSelection {
+	cfg := Current()
+	sel := DefaultServerSelection
+	if cfg == nil || cfg.ServerSelection == nil {
+		return sel
+	}
+
+	custom := cfg.ServerSelection
+	if total := custom.LoadWeight + custom.LatencyWeight; total > 0 {
+		sel.LoadWeight = custom.LoadWeight / total
+		sel.LatencyWeight = custom.LatencyWeight / total
+	}
+	if custom.OverloadPercent > 0 {
+		sel.OverloadPercent = custom.OverloadPercent
+	}
+	sel.WaitWhenBusy = custom.WaitWhenBusy
+	return sel
+}
+
 // GetAutoStartEnabled returns the autostart preference (default: true)
 func GetAutoStartEnabled() bool {
-	if GlobalConfig == nil || GlobalConfig.AutoStart == nil {
+	cfg := Current()
+	if cfg == nil || cfg.AutoStart == nil {
 		return true // Default to enabled
 	}
-	return *GlobalConfig.AutoStart
+	return *cfg.AutoStart
+}
+
+// GetBindAddr returns the source IP for relayed connections, or nil to let the OS choose
+func GetBindAddr() net.IP {
+	cfg := Current()
+	if cfg == nil || cfg.BindAddr == "" {
+		return nil
+	}
+	return net.ParseIP(cfg.BindAddr)
+}
+
+// isLocalIP reports whether ip is assigned to one of this host's interfaces
+func isLocalIP(ip net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return true // Can't tell - let the dial report it
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFallbackDNS returns the fallback DNS resolvers as host:port (nil if disabled)
+func GetFallbackDNS() []string {
+	cfg := Current()
+	if cfg != nil && cfg.DisableFallbackDNS {
+		return nil
+	}
+
+	servers := DefaultFallbackDNS
+	if cfg != nil && len(cfg.FallbackDNS) > 0 {
+		servers = cfg.FallbackDNS
+	}
+
+	resolvers := make([]string, 0, len(servers))
+	for _, server := range servers {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+		}
+		resolvers = append(resolvers, server)
+	}
+	return resolvers
+}
+
+// GetMemoryProfile returns the selected memory profile (default: "default")
+func GetMemoryProfile() string {
+	cfg := Current()
+	if cfg == nil || cfg.MemoryProfile == "" {
+		return MemoryProfileDefault
+	}
+	return cfg.MemoryProfile
+}
+
+// GetDataChannelBuffer returns the per-connection data channel capacity (default: 10000, 1000 on the low profile)
+func GetDataChannelBuffer() int {
+	cfg := Current()
+	if cfg == nil || cfg.DataChannelBuffer <= 0 {
+		if GetMemoryProfile() == MemoryProfileLow {
+			return LowMemoryDataChannelBuffer
+		}
+		return DefaultDataChannelBuffer
+	}
+	return cfg.DataChannelBuffer
+}
+
+// IsPortAllowed reports whether relaying to a destination port is permitted by allowed_ports
+func IsPortAllowed(port int) bool {
+	cfg := Current()
+	if cfg == nil || len(cfg.AllowedPorts) == 0 {
+		return true
+	}
+	for _, allowed := range cfg.AllowedPorts {
+		if port == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMaxConnsPerHost returns the concurrent connection limit per destination host (0 = unlimited)
+func GetMaxConnsPerHost() int {
+	cfg := Current()
+	if cfg == nil {
+		return 0
+	}
+	return cfg.MaxConnsPerHost
+}
+
+// GetConnectRateLimit returns the new-connection rate (per second) and burst; rate 0 means unlimited
+func GetConnectRateLimit() (float64, int) {
+	cfg := Current()
+	if cfg == nil || cfg.ConnectRateLimit <= 0 {
+		return 0, 0
+	}
+	burst := cfg.ConnectBurst
+	if burst <= 0 {
+		burst = int(math.Ceil(cfg.ConnectRateLimit))
+	}
+	return cfg.ConnectRateLimit, burst
+}
+
+// GetAuthTimeout returns how long to wait for the browser login callback (default: 120s)
+func GetAuthTimeout() time.Duration {
+	cfg := Current()
+	if cfg == nil || cfg.AuthTimeoutSeconds <= 0 {
+		return DefaultAuthTimeout
+	}
+	return time.Duration(cfg.AuthTimeoutSeconds) * time.Second
 }
 
 // SetAutoStartEnabled sets the autostart preference
 func SetAutoStartEnabled(enabled bool) error {
-	if GlobalConfig == nil {
-		return fmt.Errorf("config not initialized")
+	updated, err := UpdateConfig(func(config *Config) { config.AutoStart = &enabled })
+	if err != nil {
+		return err
 	}
+	return saveSettings(updated)
+}
+
+// GetDebugMode returns whether local development mode is on (config.json or the -debug flag)
+func GetDebugMode() bool {
+	cfg := Current()
+	return cfg != nil && cfg.DebugMode
+}
+
+// GetVerboseLogging returns whether logs may include destinations and account details (default: false)
+func GetVerboseLogging() bool {
+	cfg := Current()
+	return cfg != nil && cfg.VerboseLogging
+}
+
+// GetTelemetryEnabled returns the anonymous telemetry preference (default: false)
+func GetTelemetryEnabled() bool {
+	cfg := Current()
+	return cfg != nil && cfg.Telemetry
+}
 
-	GlobalConfig.AutoStart = &enabled
-	return SaveConfig(GlobalConfig)
+// GetTokenValidationInterval returns how often to re-validate the stored token (0 = disabled)
+func GetTokenValidationInterval() time.Duration {
+	cfg := Current()
+	if cfg == nil {
+		return 0
+	}
+	return time.Duration(cfg.TokenValidationMinutes) * time.Minute
+}
+
+// GetUpdateURL returns the release endpoint for auto-update: update_url (or VYX_UPDATE_URL), then GitHub
+func GetUpdateURL() string {
+	cfg := Current()
+	if cfg != nil && cfg.UpdateURL != "" {
+		return cfg.UpdateURL
+	}
+	return DefaultUpdateURL
+}
+
+// GetUpdateMirrors returns the fallback download mirrors (empty by default)
+func GetUpdateMirrors() []string {
+	cfg := Current()
+	if cfg == nil {
+		return nil
+	}
+	return cfg.UpdateMirrors
+}
+
+// isValidUpdateURL reports whether raw is an absolute http(s) URL with a host
+func isValidUpdateURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// GetWorkerStreams returns how many streams should carry relay traffic (default: 1)
+func GetWorkerStreams() int {
+	cfg := Current()
+	if cfg == nil || cfg.WorkerStreams <= 0 {
+		return 1
+	}
+	return cfg.WorkerStreams
+}
+
+// GetRequireTLS13 returns whether production connections must use TLS 1.3 (default: false)
+func GetRequireTLS13() bool {
+	cfg := Current()
+	return cfg != nil && cfg.RequireTLS13
+}
+
+// GetTCPFallbackEnabled returns whether the TLS/TCP fallback transport may be used (default: false)
+func GetTCPFallbackEnabled() bool {
+	cfg := Current()
+	return cfg != nil && cfg.TCPFallback
+}
+
+// GetTCPFallbackPort returns the TCP port for the fallback transport (0 = same as the QUIC port)
+func GetTCPFallbackPort() int {
+	cfg := Current()
+	if cfg == nil {
+		return 0
+	}
+	return cfg.TCPFallbackPort
+}
+
+// IsFirstRun reports whether the first-launch onboarding hasn't happened yet
+func IsFirstRun() bool {
+	cfg := Current()
+	return cfg == nil || !cfg.FirstRunCompleted
+}
+
+// MarkFirstRunCompleted records that onboarding ran, so it isn't repeated on later starts
+func MarkFirstRunCompleted() error {
+	if cfg := Current(); cfg != nil && cfg.FirstRunCompleted {
+		return nil
+	}
+
+	updated, err := UpdateConfig(func(config *Config) { config.FirstRunCompleted = true })
+	if err != nil {
+		return err
+	}
+	return saveSettings(updated)
+}
+
+// GetLastServer returns the address of the last successfully used server ("" if none)
+func GetLastServer() string {
+	cfg := Current()
+	if cfg == nil {
+		return ""
+	}
+	return cfg.LastServer
+}
+
+// SetLastServer persists the last successfully used server address
+// Skips the write when unchanged so routine reconnects don't rewrite the config file
+func SetLastServer(addr string) error {
+	if cfg := Current(); cfg != nil && cfg.LastServer == addr {
+		return nil
+	}
+
+	updated, err := UpdateConfig(func(config *Config) { config.LastServer = addr })
+	if err != nil {
+		return err
+	}
+	return saveSettings(updated)
+}
+
+// SetTelemetryEnabled sets the anonymous telemetry preference
+func SetTelemetryEnabled(enabled bool) error {
+	updated, err := UpdateConfig(func(config *Config) { config.Telemetry = enabled })
+	if err != nil {
+		return err
+	}
+	return saveSettings(updated)
 }
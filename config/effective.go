@@ -0,0 +1,37 @@
+package config
+
+// EffectiveConfig returns the settings in use: config.json and VYX_* overrides, with defaults filled in
+// Run-time flags (e.g. -debug) are applied by the caller; the API token is never part of Config's JSON
+func EffectiveConfig() Config {
+	var effective Config
+	if cfg := Current(); cfg != nil {
+		effective = *cfg
+	}
+	effective.APIToken = ""
+
+	if effective.ServerURL == "" {
+		effective.ServerURL = DefaultServerURL
+	}
+	if effective.LogLevel == "" {
+		effective.LogLevel = "info"
+	}
+	if effective.LogTimestamps == "" {
+		effective.LogTimestamps = "local"
+	}
+	autoStart := GetAutoStartEnabled()
+	effective.AutoStart = &autoStart
+
+	effective.FallbackDNS = GetFallbackDNS()
+	effective.MemoryProfile = GetMemoryProfile()
+	effective.DataChannelBuffer = GetDataChannelBuffer()
+	effective.AuthTimeoutSeconds = int(GetAuthTimeout().Seconds())
+	effective.ConnectRateLimit, effective.ConnectBurst = GetConnectRateLimit()
+	effective.WorkerStreams = GetWorkerStreams()
+	effective.UpdateURL = GetUpdateURL()
+
+	paths := GetAPIPaths()
+	effective.APIPaths = &paths
+	selection := GetServerSelection()
+	effective.ServerSelection = &selection
+	return effective
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EnvPrefix starts every config override variable: server_url is VYX_SERVER_URL,
+// api_paths.servers is VYX_API_PATHS_SERVERS
+const EnvPrefix = "VYX_"
+
+// envExcluded are fields managed by the client itself, which the environment can't override
+var envExcluded = map[string]bool{
+	"version":             true,
+	"user_id":             true,
+	"email":               true,
+	"last_server":         true,
+	"first_run_completed": true,
+}
+
+// envOverride is a Config field set from the environment
+type envOverride struct {
+	index int      // Field index in Config
+	name  string   // JSON key, e.g. "auto_start"
+	vars  []string // Variables that set it
+}
+
+var (
+	// fileSettings is config.json's content, validated and migrated, without environment overrides
+	// SaveConfig writes its values for overridden fields, so overrides never end up in config.json
+	fileSettings Config
+	// pinnedSettings is the validated config with the overrides applied; a save that differs from it
+	// in an overridden field was changed at runtime (e.g. a tray toggle) and is only kept until restart
+	pinnedSettings Config
+	envOverridden  []envOverride
+	envMutex       sync.Mutex
+)
+
+// applyEnvOverrides sets config fields from VYX_* variables; env wins over the file
+// Empty variables are ignored; invalid values are logged and ignored
+func applyEnvOverrides(config *Config) {
+	file := normalizedCopy(*config)
+
+	var overridden []envOverride
+	var applied []string
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := jsonFieldName(value.Type().Field(i))
+		if name == "" || envExcluded[name] {
+			continue
+		}
+		if vars := setFromEnv(value.Field(i), EnvPrefix+strings.ToUpper(name)); len(vars) > 0 {
+			overridden = append(overridden, envOverride{index: i, name: name, vars: vars})
+			applied = append(applied, vars...)
+		}
+	}
+
+	envMutex.Lock()
+	fileSettings = file
+	pinnedSettings = normalizedCopy(*config)
+	envOverridden = overridden
+	envMutex.Unlock()
+
+	// Names only: values such as http_proxy may carry credentials
+	if len(applied) > 0 {
+		log.Printf("Config overridden by environment: %s", strings.Join(applied, ", "))
+	}
+}
+
+// normalizedCopy returns a deep copy of config after validation and migration, without logging
+// The caller validates and migrates the real config itself, which reports any problems once
+func normalizedCopy(config Config) Config {
+	var clone Config
+	if data, err := json.Marshal(config); err == nil {
+		json.Unmarshal(data, &clone)
+	}
+	discard := func(string, ...interface{}) {}
+	validateConfigWith(&clone, discard)
+	migrateConfigWith(&clone, discard)
+	return clone
+}
+
+// setFromEnv sets field from variable env, or each field of a nested struct from env_<FIELD>
+// Returns the variables that were applied
+func setFromEnv(field reflect.Value, env string) []string {
+	if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct {
+		nested := reflect.New(field.Type().Elem())
+		if !field.IsNil() {
+			nested.Elem().Set(field.Elem())
+		}
+
+		var applied []string
+		for i := 0; i < nested.Elem().NumField(); i++ {
+			name := jsonFieldName(nested.Elem().Type().Field(i))
+			if name == "" {
+				continue
+			}
+			applied = append(applied, setFromEnv(nested.Elem().Field(i), env+"_"+strings.ToUpper(name))...)
+		}
+		if len(applied) > 0 {
+			field.Set(nested) // A copy, so fileSettings keeps the file's values
+		}
+		return applied
+	}
+
+	raw := strings.TrimSpace(os.Getenv(env))
+	if raw == "" {
+		return nil
+	}
+	if err := setFieldValue(field, raw); err != nil {
+		log.Printf("Warning: %s %q is invalid (%v), ignoring it", env, raw, err)
+		return nil
+	}
+	return []string{env}
+}
+
+// setFieldValue parses raw into field; lists are comma-separated
+func setFieldValue(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("expected a whole number")
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		field.SetFloat(f)
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setFieldValue(elem.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.Slice:
+		parts := strings.Split(raw, ",")
+		list := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setFieldValue(list.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		field.Set(list)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// jsonFieldName returns the JSON key of a struct field ("" for fields not stored in config.json)
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// withoutEnvOverrides returns config with environment-overridden fields put back to their file values
+// A runtime change to an overridden field is logged, since it won't be saved and ends at restart
+func withoutEnvOverrides(config Config) Config {
+	envMutex.Lock()
+	defer envMutex.Unlock()
+
+	value := reflect.ValueOf(&config).Elem()
+	file := reflect.ValueOf(fileSettings)
+	pinned := reflect.ValueOf(&pinnedSettings).Elem()
+	for _, o := range envOverridden {
+		if field := value.Field(o.index); !reflect.DeepEqual(field.Interface(), pinned.Field(o.index).Interface()) {
+			log.Printf("Warning: %s is set by %s, the change applies until restart but isn't saved to config.json",
+				o.name, strings.Join(o.vars, ", "))
+			pinned.Field(o.index).Set(field) // Report each change once
+		}
+		value.Field(o.index).Set(file.Field(o.index))
+	}
+	return config
+}
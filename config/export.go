@@ -0,0 +1,82 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// portableConfig strips per-machine and account fields so a config can be shared between nodes
+// The API token is never part of Config's JSON, so it is excluded automatically
+func portableConfig(config Config) Config {
+	config.UserID = ""
+	config.Email = ""
+	config.LastServer = ""
+	config.FirstRunCompleted = false
+	return config
+}
+
+// ExportConfig writes the non-secret settings from config.json (without env overrides) to path as JSON
+func ExportConfig(path string) error {
+	cfg := Current()
+	if cfg == nil {
+		return fmt.Errorf("config not initialized")
+	}
+
+	data, err := json.MarshalIndent(portableConfig(withoutEnvOverrides(*cfg)), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// ImportConfig validates settings exported by ExportConfig and applies them, keeping the current login
+// Unknown fields are rejected so typos and files from other tools fail instead of being half-applied
+func ImportConfig(path string) error {
+	if Current() == nil {
+		return fmt.Errorf("config not initialized")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var imported Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&imported); err != nil {
+		return fmt.Errorf("%s is not a valid Vyx config: %w", path, err)
+	}
+	if imported.Version > CurrentConfigVersion {
+		return fmt.Errorf("%s uses config version %d, newer than this client supports (%d) - update Vyx first",
+			path, imported.Version, CurrentConfigVersion)
+	}
+
+	// Overrides apply on top of imported settings too; SaveConfig writes the imported values
+	applyEnvOverrides(&imported)
+	validateConfig(&imported)
+	migrateConfig(&imported)
+
+	// Keep this machine's identity; the token stays in the keyring
+	imported = portableConfig(imported)
+	keepIdentity := func(from *Config) {
+		imported.APIToken = from.APIToken
+		imported.UserID = from.UserID
+		imported.Email = from.Email
+		imported.LastServer = from.LastServer
+		imported.FirstRunCompleted = from.FirstRunCompleted
+	}
+	keepIdentity(Current())
+
+	// Only apply settings that were saved, so a failed import changes nothing
+	if err := saveSettings(&imported); err != nil {
+		return err
+	}
+	_, err = UpdateConfig(func(config *Config) {
+		keepIdentity(config) // The login may have changed during the save
+		*config = imported
+	})
+	return err
+}
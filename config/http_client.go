@@ -0,0 +1,116 @@
+package config
+
+import (
+	"client/version"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+)
+
+// maxUserAgentTagLen keeps user_agent_tag short enough for access logs
+const maxUserAgentTagLen = 64
+
+// Timeouts for NewHTTPClient, so similar requests behave the same everywhere
+const (
+	HTTPTimeoutQuick    = 5 * time.Second  // Server discovery - a slow API should fall back fast
+	HTTPTimeoutDefault  = 15 * time.Second // API calls and update checks
+	HTTPTimeoutDownload = 5 * time.Minute  // Update downloads (tens of MB on slow links)
+)
+
+// sharedTransport pools connections across every client from NewHTTPClient
+var sharedTransport = newSharedTransport()
+
+// newSharedTransport clones the default transport with proxy and TLS policy applied
+func newSharedTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyForRequest
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	transport.MaxIdleConnsPerHost = 4
+	return transport
+}
+
+// proxyForRequest uses http_proxy from config when set, otherwise HTTPS_PROXY/HTTP_PROXY/NO_PROXY
+// Read per request so a hot-reloaded config applies to new connections
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	cfg := Current()
+	if cfg == nil || cfg.HTTPProxy == "" {
+		return http.ProxyFromEnvironment(req)
+	}
+	if isLoopbackHost(req.URL.Hostname()) {
+		return nil, nil // Debug-mode API on localhost
+	}
+	return url.Parse(cfg.HTTPProxy)
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback IP
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isValidProxyURL reports whether raw is an http, https or socks5 proxy URL with a host
+func isValidProxyURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+		return true
+	}
+	return false
+}
+
+// UserAgent returns the User-Agent sent on API and update requests
+// e.g. "Vyx-Client/v0.1.1 (linux/amd64)" or, with user_agent_tag set, "Vyx-Client/v0.1.1 (linux/amd64) acme-fleet"
+func UserAgent() string {
+	ua := fmt.Sprintf("Vyx-Client/%s (%s/%s)", version.Version, runtime.GOOS, runtime.GOARCH)
+	if cfg := Current(); cfg != nil && cfg.UserAgentTag != "" {
+		ua += " " + cfg.UserAgentTag
+	}
+	return ua
+}
+
+// NewHTTPClient returns an HTTP client for Vyx services and update servers
+// Clients share one pooled transport (proxy and TLS policy) and send UserAgent on every request
+// Pass one of the HTTPTimeout constants
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: userAgentTransport{base: sharedTransport},
+	}
+}
+
+// userAgentTransport sets the User-Agent header unless the request already has one
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent())
+	}
+	return t.base.RoundTrip(req)
+}
+
+// isValidUserAgentTag allows short printable ASCII without spaces or characters that break the header
+func isValidUserAgentTag(tag string) bool {
+	if len(tag) > maxUserAgentTagLen {
+		return false
+	}
+	for _, r := range tag {
+		if r <= ' ' || r > '~' || r == '(' || r == ')' {
+			return false
+		}
+	}
+	return true
+}
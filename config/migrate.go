@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// CurrentConfigVersion is the schema version written by this client
+// Bump it and add an entry to configMigrations when the file format changes
+const CurrentConfigVersion = 1
+
+// configMigrations upgrades a config from the keyed version to the next one
+var configMigrations = map[int]func(config *Config){
+	// 0 -> 1: unversioned configs; no field changes, just stamp the version
+	0: func(config *Config) {},
+}
+
+// migrateConfig runs every migration from config.Version up to CurrentConfigVersion
+// Returns true if the config changed and should be saved
+func migrateConfig(config *Config) bool {
+	return migrateConfigWith(config, log.Printf)
+}
+
+// migrateConfigWith is migrateConfig reporting through logf
+func migrateConfigWith(config *Config, logf func(format string, v ...interface{})) bool {
+	if config.Version > CurrentConfigVersion {
+		logf("Warning: config version %d is newer than supported version %d (downgraded client?)",
+			config.Version, CurrentConfigVersion)
+		return false
+	}
+
+	migrated := false
+	for config.Version < CurrentConfigVersion {
+		if migrate, ok := configMigrations[config.Version]; ok {
+			migrate(config)
+		}
+		logf("Migrated config from version %d to %d", config.Version, config.Version+1)
+		config.Version++
+		migrated = true
+	}
+	return migrated
+}
+
+// backupBrokenConfig moves an unreadable config aside so the user's settings aren't lost
+// Returns the backup path
+func backupBrokenConfig(configPath string) (string, error) {
+	backupPath := fmt.Sprintf("%s.broken-%s", configPath, time.Now().Format("20060102-150405"))
+	if err := os.Rename(configPath, backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// configWatchInterval is how often config.json is checked for external edits
+const configWatchInterval = 5 * time.Second
+
+// debugFlag records that debug mode was forced by the -debug flag, so reloads keep it on
+var debugFlag bool
+
+var (
+	// savedHash is the SHA-256 of the config.json content last written by SaveConfig
+	// WatchConfig skips those writes: they are the app's own changes, already in effect
+	savedHash  [sha256.Size]byte
+	savedMutex sync.Mutex
+)
+
+// ForceDebugMode enables debug mode for this run regardless of config.json
+func ForceDebugMode() {
+	debugFlag = true
+	UpdateConfig(func(config *Config) { config.DebugMode = true })
+}
+
+// rememberSave records content SaveConfig wrote to config.json
+func rememberSave(data []byte) {
+	savedMutex.Lock()
+	savedHash = sha256.Sum256(data)
+	savedMutex.Unlock()
+}
+
+// isOwnSave reports whether data is the content SaveConfig last wrote
+func isOwnSave(data []byte) bool {
+	hash := sha256.Sum256(data)
+	savedMutex.Lock()
+	defer savedMutex.Unlock()
+	return hash == savedHash
+}
+
+// WatchConfig polls config.json and applies external edits without a restart
+// Writes made by the app itself (SaveConfig) are not reloaded
+// onChange is called with the previous and new settings after the current config has been updated
+// Blocks forever; run it in a goroutine
+func WatchConfig(onChange func(old, updated Config)) {
+	lastMod := configModTime()
+	for {
+		time.Sleep(configWatchInterval)
+
+		mod := configModTime()
+		if mod.IsZero() || mod.Equal(lastMod) {
+			continue
+		}
+		lastMod = mod
+
+		if data, err := os.ReadFile(getConfigPath()); err != nil || isOwnSave(data) {
+			continue
+		}
+
+		old, updated, err := ReloadConfig()
+		if err != nil {
+			log.Printf("Warning: Config reload skipped: %v", err)
+			continue
+		}
+		if onChange != nil {
+			onChange(old, updated)
+		}
+	}
+}
+
+// ReloadConfig re-reads config.json into the current config, keeping the current session's login
+// An invalid file is ignored (the user may be mid-edit) rather than replaced with defaults
+func ReloadConfig() (old Config, updated Config, err error) {
+	if Current() == nil {
+		return Config{}, Config{}, fmt.Errorf("config not initialized")
+	}
+
+	data, err := os.ReadFile(getConfigPath())
+	if err != nil {
+		return Config{}, Config{}, err
+	}
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return Config{}, Config{}, fmt.Errorf("config file is not valid JSON: %w", err)
+	}
+	applyEnvOverrides(&updated)
+	validateConfig(&updated)
+	migrated := migrateConfig(&updated)
+
+	applied, err := UpdateConfig(func(config *Config) {
+		old = *config
+
+		// Login state is owned by the app, not the file
+		updated.APIToken = old.APIToken
+		updated.UserID = old.UserID
+		updated.Email = old.Email
+		updated.DebugMode = updated.DebugMode || debugFlag
+		*config = updated
+	})
+	if err != nil {
+		return Config{}, Config{}, err
+	}
+	if migrated {
+		if err := saveSettings(applied); err != nil {
+			log.Printf("Warning: Failed to save migrated config: %v", err)
+		}
+	}
+	log.Println("Config reloaded from disk")
+	return old, updated, nil
+}
+
+// configModTime returns config.json's modification time (zero if missing)
+func configModTime() time.Time {
+	info, err := os.Stat(getConfigPath())
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+// SecretBackendEnv selects where tokens are stored: "keyring" (default), "file" or "memory"
+const SecretBackendEnv = "VYX_SECRET_BACKEND"
+
+// SecureStore stores one secret per (service, user)
+// Implementations must report missing secrets as keyring.ErrNotFound
+type SecureStore interface {
+	Set(service, user, secret string) error
+	Get(service, user string) (string, error)
+	Delete(service, user string) error
+}
+
+var (
+	selectedBackend     SecureStore
+	selectedBackendOnce sync.Once
+	selectedBackendMu   sync.RWMutex
+)
+
+// SetSecureStore replaces the store used by all SecureStorage instances
+// Intended for tests (e.g. NewMemorySecureStore) and embedders; pass nil to restore the default
+func SetSecureStore(store SecureStore) {
+	// Mark the env selection as done so it can't overwrite an injected store later
+	selectedBackendOnce.Do(func() {})
+	selectedBackendMu.Lock()
+	defer selectedBackendMu.Unlock()
+	if store == nil {
+		store = backendFromEnv()
+	}
+	selectedBackend = store
+}
+
+// getSecretBackend returns the injected store, or the one chosen by VYX_SECRET_BACKEND (resolved once)
+func getSecretBackend() SecureStore {
+	selectedBackendOnce.Do(func() {
+		selectedBackendMu.Lock()
+		defer selectedBackendMu.Unlock()
+		selectedBackend = backendFromEnv()
+	})
+	selectedBackendMu.RLock()
+	defer selectedBackendMu.RUnlock()
+	return selectedBackend
+}
+
+// backendFromEnv builds the store named by VYX_SECRET_BACKEND
+func backendFromEnv() SecureStore {
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv(SecretBackendEnv))); name {
+	case "", "keyring":
+		return keyringBackend{}
+	case "file":
+		// SECURITY: plaintext on disk (0600) - meant for containers/CI without a keyring
+		log.Printf("WARNING: %s=file stores the API token unencrypted under ~/.vyx/secrets", SecretBackendEnv)
+		return fileBackend{dir: getSecretsDir()}
+	case "memory":
+		log.Printf("%s=memory: tokens are kept in memory only and lost on exit", SecretBackendEnv)
+		return NewMemorySecureStore()
+	default:
+		log.Printf("Warning: Unknown %s %q, using OS keyring", SecretBackendEnv, name)
+		return keyringBackend{}
+	}
+}
+
+// keyringBackend uses the OS keyring, with a timeout so a blocking prompt can't hang the app
+type keyringBackend struct{}
+
+func (keyringBackend) Set(service, user, secret string) error {
+	return withKeyringTimeout(func() error {
+		return keyring.Set(service, user, secret)
+	})
+}
+
+func (keyringBackend) Get(service, user string) (string, error) {
+	var secret string
+	err := withKeyringTimeout(func() error {
+		var getErr error
+		secret, getErr = keyring.Get(service, user)
+		return getErr
+	})
+	return secret, err
+}
+
+func (keyringBackend) Delete(service, user string) error {
+	return withKeyringTimeout(func() error {
+		return keyring.Delete(service, user)
+	})
+}
+
+// MemorySecureStore keeps secrets in process memory (nothing persists across restarts)
+type MemorySecureStore struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// NewMemorySecureStore creates an empty in-memory store
+func NewMemorySecureStore() *MemorySecureStore {
+	return &MemorySecureStore{secrets: make(map[string]string)}
+}
+
+func (m *MemorySecureStore) Set(service, user, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[service+"/"+user] = secret
+	return nil
+}
+
+func (m *MemorySecureStore) Get(service, user string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	secret, ok := m.secrets[service+"/"+user]
+	if !ok {
+		return "", keyring.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (m *MemorySecureStore) Delete(service, user string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.secrets[service+"/"+user]; !ok {
+		return keyring.ErrNotFound
+	}
+	delete(m.secrets, service+"/"+user)
+	return nil
+}
+
+// fileBackend stores each secret in its own owner-only file
+type fileBackend struct {
+	dir string
+}
+
+// getSecretsDir returns the directory used by the file backend
+func getSecretsDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".vyx", "secrets")
+}
+
+func (f fileBackend) path(service, user string) string {
+	// Keep user-controlled IDs from escaping the secrets directory
+	name := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(service + "-" + user)
+	return filepath.Join(f.dir, name)
+}
+
+func (f fileBackend) Set(service, user, secret string) error {
+	if err := os.MkdirAll(f.dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path(service, user), []byte(secret), 0600)
+}
+
+func (f fileBackend) Get(service, user string) (string, error) {
+	data, err := os.ReadFile(f.path(service, user))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", keyring.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (f fileBackend) Delete(service, user string) error {
+	err := os.Remove(f.path(service, user))
+	if errors.Is(err, os.ErrNotExist) {
+		return keyring.ErrNotFound
+	}
+	return err
+}
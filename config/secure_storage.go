@@ -1,9 +1,13 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/zalando/go-keyring"
 )
@@ -15,14 +19,85 @@ const (
 	KeyringTokenKey = "api-token"
 )
 
+// ErrKeyringUnavailable is returned when the OS keyring doesn't respond in time
+// (e.g. a GUI unlock prompt in a headless or locked session)
+var ErrKeyringUnavailable = errors.New("keyring unavailable")
+
+// ErrTokenNotPersisted is returned by SaveConfig when config.json was written but the token couldn't be stored
+// The login still works for this session; after a restart the user has to log in again
+var ErrTokenNotPersisted = errors.New("login not persisted")
+
+var (
+	// storedToken is the token last successfully written to (or read from) secure storage
+	// SaveConfig skips the keyring while the token is unchanged, and retries it until a write succeeds
+	storedToken      string
+	storedTokenMutex sync.Mutex
+)
+
+// tokenNeedsSave reports whether token differs from the one known to be in secure storage
+func tokenNeedsSave(token string) bool {
+	storedTokenMutex.Lock()
+	defer storedTokenMutex.Unlock()
+	return token != storedToken
+}
+
+// markTokenStored records token as the one in secure storage ("" after deleting it)
+func markTokenStored(token string) {
+	storedTokenMutex.Lock()
+	storedToken = token
+	storedTokenMutex.Unlock()
+}
+
+const (
+	// keyringTimeout bounds each keyring call so a blocking prompt can't freeze the app
+	keyringTimeout = 5 * time.Second
+	// keyringRetryAfter is how long to fail fast after a timeout before trying the keyring again
+	keyringRetryAfter = 1 * time.Minute
+)
+
+var (
+	keyringDownUntil time.Time
+	keyringMutex     sync.Mutex
+)
+
+// withKeyringTimeout runs a keyring operation, giving up after keyringTimeout
+// After a timeout the keyring is treated as unavailable for keyringRetryAfter so
+// frequent callers (e.g. IsLoggedIn) don't each block and leak a goroutine
+func withKeyringTimeout(op func() error) error {
+	keyringMutex.Lock()
+	down := time.Now().Before(keyringDownUntil)
+	keyringMutex.Unlock()
+	if down {
+		return ErrKeyringUnavailable
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(keyringTimeout):
+		keyringMutex.Lock()
+		keyringDownUntil = time.Now().Add(keyringRetryAfter)
+		keyringMutex.Unlock()
+		log.Printf("Warning: OS keyring did not respond within %v (locked session or unlock prompt?)", keyringTimeout)
+		return fmt.Errorf("%w: no response after %v", ErrKeyringUnavailable, keyringTimeout)
+	}
+}
+
 // SecureStorage provides cross-platform secure credential storage
-// Uses OS-specific keyrings:
+// Uses OS-specific keyrings by default:
 // - Windows: Windows Credential Manager
 // - macOS: Keychain
 // - Linux: Secret Service API (gnome-keyring, kwallet)
+// VYX_SECRET_BACKEND=file|memory overrides this for containers, CI and testing
 type SecureStorage struct {
 	service string
 	userID  string
+	backend SecureStore
 }
 
 // NewSecureStorage creates a new secure storage instance
@@ -34,28 +109,32 @@ func NewSecureStorage(userID string) *SecureStorage {
 	return &SecureStorage{
 		service: KeyringService,
 		userID:  userID,
+		backend: getSecretBackend(),
 	}
 }
 
-// SaveToken securely stores the API token in the OS keyring
+// SaveToken securely stores the API token in the configured backend (OS keyring by default)
 func (s *SecureStorage) SaveToken(token string) error {
 	if token == "" {
 		return errors.New("token cannot be empty")
 	}
 
-	err := keyring.Set(s.service, s.userID, token)
+	err := s.backend.Set(s.service, s.userID, token)
 	if err != nil {
 		return fmt.Errorf("failed to save token to secure storage: %w", err)
 	}
 
-	log.Printf("Token securely saved for user: %s", s.userID)
+	log.Printf("Token securely saved for user: %s", RedactPersonal(s.userID))
 	return nil
 }
 
-// GetToken retrieves the API token from the OS keyring
+// GetToken retrieves the API token from the configured backend
 func (s *SecureStorage) GetToken() (string, error) {
-	token, err := keyring.Get(s.service, s.userID)
+	token, err := s.backend.Get(s.service, s.userID)
 	if err != nil {
+		if errors.Is(err, ErrKeyringUnavailable) {
+			return "", err
+		}
 		// Check if token doesn't exist (common case for new installations)
 		if errors.Is(err, keyring.ErrNotFound) {
 			return "", fmt.Errorf("no token found in secure storage (user: %s)", s.userID)
@@ -70,19 +149,20 @@ func (s *SecureStorage) GetToken() (string, error) {
 	return token, nil
 }
 
-// DeleteToken removes the API token from the OS keyring
+// DeleteToken removes the API token from the configured backend
 func (s *SecureStorage) DeleteToken() error {
-	err := keyring.Delete(s.service, s.userID)
+	err := s.backend.Delete(s.service, s.userID)
 	if err != nil {
 		// Ignore error if token doesn't exist
 		if errors.Is(err, keyring.ErrNotFound) {
-			log.Printf("Token not found in secure storage (user: %s), nothing to delete", s.userID)
+			log.Printf("Token not found in secure storage (user: %s), nothing to delete", RedactPersonal(s.userID))
 			return nil
 		}
 		return fmt.Errorf("failed to delete token from secure storage: %w", err)
 	}
+	markTokenStored("") // A later login must store its token again, even an identical one
 
-	log.Printf("Token securely deleted for user: %s", s.userID)
+	log.Printf("Token securely deleted for user: %s", RedactPersonal(s.userID))
 	return nil
 }
 
@@ -100,10 +180,30 @@ func MigrateFromPlaintextConfig(plaintextToken, userID string) error {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
-	log.Printf("Successfully migrated token to secure storage for user: %s", userID)
+	log.Printf("Successfully migrated token to secure storage for user: %s", RedactPersonal(userID))
 	return nil
 }
 
+// RedactToken returns a log-safe stand-in for an API token: a short SHA-256 fingerprint
+// SECURITY: never log any part of the token itself - logs get shared for support
+// The fingerprint can't be reversed but still shows whether two log lines used the same token
+func RedactToken(token string) string {
+	if token == "" {
+		return "<none>"
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
+
+// RedactPersonal returns value (an email or user ID) for logging, or "<redacted>" unless verbose_logging is on
+// PRIVACY: like destinations, account details stay out of logs that users share for support
+func RedactPersonal(value string) string {
+	if value == "" || GetVerboseLogging() {
+		return value
+	}
+	return "<redacted>"
+}
+
 // HasToken checks if a token exists in secure storage without retrieving it
 func (s *SecureStorage) HasToken() bool {
 	_, err := s.GetToken()
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"client/config"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// effectiveConfigView is what `vyx config` prints: the effective settings plus run-time overrides
+type effectiveConfigView struct {
+	config.Config
+	// SECURITY: only whether a token is stored, never the token itself
+	APIToken     string `json:"api_token,omitempty"`
+	ForcedServer string `json:"forced_server,omitempty"`
+}
+
+// runConfigCommand handles `vyx config`: prints the merged settings (defaults, config.json, env and flags) as JSON
+// Flags go before the subcommand, e.g. `vyx-client -quiet config` shows log_level as "warn"
+func runConfigCommand() int {
+	if _, err := config.LoadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not load config: %v\n", err)
+		return 1
+	}
+	if *debugMode {
+		config.ForceDebugMode()
+	}
+
+	view := effectiveConfigView{Config: config.EffectiveConfig()}
+	if config.IsLoggedIn() {
+		view.APIToken = "<redacted>"
+	}
+	if *quiet {
+		view.LogLevel = "warn"
+	}
+	if *logUTC {
+		view.LogTimestamps = "iso-utc"
+	}
+	if !view.DebugMode {
+		view.ForcedServer = *forceServer
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false) // Keep "<redacted>" and URLs readable
+	if err := encoder.Encode(view); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not encode config: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runExportConfigCommand handles `vyx export-config <file>`: saves the non-secret settings for other machines
+func runExportConfigCommand(path string) int {
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "Usage: vyx-client export-config <file>")
+		return 2
+	}
+	if _, err := config.LoadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not load config: %v\n", err)
+		return 1
+	}
+
+	if err := config.ExportConfig(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Settings exported to %s (login and token not included)\n", path)
+	return 0
+}
+
+// runImportConfigCommand handles `vyx import-config <file>`: validates and applies exported settings
+func runImportConfigCommand(path string) int {
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "Usage: vyx-client import-config <file>")
+		return 2
+	}
+	if _, err := config.LoadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not load config: %v\n", err)
+		return 1
+	}
+
+	if err := config.ImportConfig(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Import failed: %v\n", err)
+		return 1
+	}
+	fmt.Println("Settings imported. A running Vyx instance picks them up automatically.")
+	if !config.IsLoggedIn() {
+		fmt.Println("This machine isn't logged in yet - start Vyx to log in, or run `vyx-client login`.")
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package conn
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maintenance backoff: base delay plus random jitter so clients don't reconnect in lockstep
+const (
+	maintenanceBaseDelay = 2 * time.Minute
+	maintenanceMaxJitter = 90 * time.Second
+)
+
+// maxServerRetryAfter caps server-provided retry hints to avoid stalling forever on bad input
+const maxServerRetryAfter = 1 * time.Hour
+
+// serverAuthError is an auth rejection sent by the server as an "error" message
+type serverAuthError struct {
+	message    string
+	retryAfter time.Duration // Server-requested backoff (0 if not provided)
+	code       string        // Optional machine-readable code (e.g. "maintenance")
+}
+
+func (e *serverAuthError) Error() string {
+	return "server rejected authentication: " + e.message
+}
+
+// newServerAuthError builds a serverAuthError from the error message Data payload
+// Data is either plain text or JSON like {"error": "...", "retry_after": 120}
+func newServerAuthError(data string) *serverAuthError {
+	authErr := &serverAuthError{message: data}
+
+	var payload struct {
+		Error      string          `json:"error"`
+		Message    string          `json:"message"`
+		Code       string          `json:"code"`
+		RetryAfter json.RawMessage `json:"retry_after"`
+	}
+	if err := json.Unmarshal([]byte(data), &payload); err == nil {
+		if payload.Error != "" {
+			authErr.message = payload.Error
+		} else if payload.Message != "" {
+			authErr.message = payload.Message
+		}
+		authErr.retryAfter = parseRetryAfter(strings.Trim(string(payload.RetryAfter), `"`))
+		authErr.code = strings.ToLower(payload.Code)
+		return authErr
+	}
+
+	// Plain text: look for "retry-after: N" / "retry_after=N"
+	lower := strings.ToLower(data)
+	for _, key := range []string{"retry-after", "retry_after"} {
+		idx := strings.Index(lower, key)
+		if idx < 0 {
+			continue
+		}
+		value := strings.TrimLeft(data[idx+len(key):], " :=")
+		if end := strings.IndexAny(value, " ,;)"); end >= 0 {
+			value = value[:end]
+		}
+		authErr.retryAfter = parseRetryAfter(value)
+		break
+	}
+
+	return authErr
+}
+
+// parseRetryAfter parses a retry hint as seconds ("120") or a Go duration ("2m")
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if d, err := time.ParseDuration(value); err == nil {
+		delay = d
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+	if delay > maxServerRetryAfter {
+		return maxServerRetryAfter
+	}
+	return delay
+}
+
+// maintenanceError signals that the server is in planned maintenance
+// Sent either as a "maintenance" message or an auth error with code "maintenance"
+type maintenanceError struct {
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *maintenanceError) Error() string {
+	if e.message == "" {
+		return "server maintenance"
+	}
+	return "server maintenance: " + e.message
+}
+
+// newMaintenanceError builds a maintenanceError from a message Data payload
+func newMaintenanceError(data string) *maintenanceError {
+	parsed := newServerAuthError(data)
+	return &maintenanceError{message: parsed.message, retryAfter: parsed.retryAfter}
+}
+
+// isMaintenance reports whether an auth rejection is actually a maintenance notice
+func (e *serverAuthError) isMaintenance() bool {
+	return e.code == "maintenance"
+}
+
+// isTokenRejected reports whether the server rejected the API token itself (revoked/expired)
+// rather than failing for another reason, meaning only a fresh login can fix it
+func (e *serverAuthError) isTokenRejected() bool {
+	switch e.code {
+	case "invalid_token", "token_expired", "token_revoked", "unauthorized":
+		return true
+	}
+	msg := strings.ToLower(e.message)
+	return strings.Contains(msg, "token") &&
+		(strings.Contains(msg, "invalid") || strings.Contains(msg, "expired") || strings.Contains(msg, "revoked"))
+}
+
+// retryDelay returns a jittered backoff for reconnecting after maintenance
+func (e *maintenanceError) retryDelay() time.Duration {
+	base := maintenanceBaseDelay
+	if e.retryAfter > 0 {
+		base = e.retryAfter
+	}
+	return base + time.Duration(rand.Int63n(int64(maintenanceMaxJitter)))
+}
+
+// serverShutdownError signals that the server is draining and steering us elsewhere
+type serverShutdownError struct {
+	nextServer string // Recommended server address (empty if none)
+	message    string
+}
+
+func (e *serverShutdownError) Error() string {
+	if e.message == "" {
+		return "server shutting down"
+	}
+	return "server shutting down: " + e.message
+}
@@ -0,0 +1,40 @@
+package conn
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// captivePortalProbeURL returns an empty 204 on an open network; portals answer with a redirect or login page
+	captivePortalProbeURL   = "http://connectivitycheck.gstatic.com/generate_204"
+	captivePortalProbeTimer = 3 * time.Second
+	captivePortalStatus     = "Captive portal detected — sign in to your network"
+)
+
+// behindCaptivePortal probes a known 204 endpoint over plain HTTP
+// Returns false when the probe itself fails (offline or blocked), so only a clearly intercepted response counts
+func behindCaptivePortal() bool {
+	client := &http.Client{
+		Timeout: captivePortalProbeTimer,
+		// Portals usually redirect; the redirect itself is the signal, don't follow it
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(captivePortalProbeURL)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return true
+	}
+
+	// Some portals rewrite the status but still inject a body
+	n, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, 1))
+	return n > 0
+}
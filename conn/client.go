@@ -0,0 +1,203 @@
+package conn
+
+import (
+	"client/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shutdownTimeout bounds how long Disconnect waits for tracked goroutines to exit
+const shutdownTimeout = 5 * time.Second
+
+// Client holds the state of a single connection to a Vyx server
+// Create one with NewClient; the package-level functions use a shared default client
+type Client struct {
+	quicConn            serverConn
+	quicStream          serverStream
+	quicMutex           sync.Mutex
+	workers             []*workerStream // Extra relay streams on quicConn (worker_streams); guarded by quicMutex
+	clientConns         map[string]*Connection
+	clientMutex         sync.RWMutex   // RWMutex for better read performance
+	hostConns           map[string]int // Open connections per destination host (max_conns_per_host)
+	hostMutex           sync.Mutex     // Guards hostConns; never held while taking another lock
+	shouldAutoReconnect bool           // Controls whether client should auto-reconnect
+	autoReconnectMutex  sync.RWMutex
+	stopReason          string                    // Why auto-reconnect was disabled by the server (empty if by the user)
+	generation          uint64                    // Bumped on every Start/Stop; guarded by autoReconnectMutex
+	stateMutex          sync.Mutex                // Serializes Start/Stop transitions with installing a new connection
+	wake                chan struct{}             // Interrupts the Connect loop's backoff sleeps after a Start/Stop
+	tasks               atomic.Pointer[taskGroup] // Goroutines of the current generation; swapped under stateMutex
+	goroutines          atomic.Int64              // Number of tracked goroutines still running, all generations
+	relaysStopping      atomic.Bool               // Set during Disconnect so relays exit without messaging the server
+	protocolVersion     atomic.Int32              // Version negotiated with the current server (0 if not authenticated)
+	state               atomic.Int32              // Current State; change only via setState
+}
+
+// NewClient creates a client with auto-reconnect enabled
+func NewClient() *Client {
+	c := &Client{
+		clientConns:         make(map[string]*Connection),
+		hostConns:           make(map[string]int),
+		shouldAutoReconnect: true,
+		wake:                make(chan struct{}, 1),
+	}
+	c.tasks.Store(newTaskGroup())
+	return c
+}
+
+// taskGroup tracks the goroutines of one connection generation
+// Unlike a sync.WaitGroup it can be waited on with a timeout without leaving a goroutine behind
+type taskGroup struct {
+	mutex   sync.Mutex
+	running int
+	idle    chan struct{} // Closed while running is 0
+}
+
+func newTaskGroup() *taskGroup {
+	idle := make(chan struct{})
+	close(idle)
+	return &taskGroup{idle: idle}
+}
+
+func (g *taskGroup) add() {
+	g.mutex.Lock()
+	if g.running == 0 {
+		g.idle = make(chan struct{})
+	}
+	g.running++
+	g.mutex.Unlock()
+}
+
+func (g *taskGroup) done() {
+	g.mutex.Lock()
+	g.running--
+	if g.running == 0 {
+		close(g.idle)
+	}
+	g.mutex.Unlock()
+}
+
+// wait blocks until the group is idle or timeout elapses; returns false on timeout
+func (g *taskGroup) wait(timeout time.Duration) bool {
+	g.mutex.Lock()
+	idle := g.idle
+	g.mutex.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// newGeneration gives the next connection generation its own task group and returns the previous one
+// Call with stateMutex held, alongside bumping c.generation
+func (c *Client) newGeneration() *taskGroup {
+	return c.tasks.Swap(newTaskGroup())
+}
+
+// spawn runs fn in a goroutine tracked by the client's lifecycle
+// Everything the client starts per connection goes through here so shutdown can wait for it
+func (c *Client) spawn(fn func()) {
+	tasks := c.tasks.Load()
+	tasks.add()
+	c.goroutines.Add(1)
+	go func() {
+		defer tasks.done()
+		defer c.goroutines.Add(-1)
+		fn()
+	}()
+}
+
+// startRelays runs both relay directions for a connection
+func (c *Client) startRelays(cc *Connection, id string) {
+	c.spawn(func() { c.relayFromConnToQuic(cc, id) })
+	c.spawn(func() { c.relayFromChanToConn(cc, id) })
+}
+
+// updateActiveConns publishes the relayed connection count to the status; call with clientMutex held
+func (c *Client) updateActiveConns() {
+	logger.GetStatus().SetActiveConns(len(c.clientConns))
+}
+
+// ProtocolVersion returns the protocol version negotiated with the current server
+// Returns 0 before the first successful authentication
+func (c *Client) ProtocolVersion() int {
+	return int(c.protocolVersion.Load())
+}
+
+// ActiveGoroutines returns how many client goroutines are still running
+// Useful for detecting leaks after Disconnect
+func (c *Client) ActiveGoroutines() int {
+	return int(c.goroutines.Load())
+}
+
+// Wait blocks until the current generation's goroutines exit or timeout elapses
+// Returns false if goroutines were still running at the timeout
+func (c *Client) Wait(timeout time.Duration) bool {
+	return c.waitTasks(c.tasks.Load(), timeout)
+}
+
+// waitTasks waits for one generation's goroutines, logging a warning on timeout
+func (c *Client) waitTasks(tasks *taskGroup, timeout time.Duration) bool {
+	if tasks.wait(timeout) {
+		return true
+	}
+	logger.Warn("%d client goroutines still running after %v", c.ActiveGoroutines(), timeout)
+	return false
+}
+
+// defaultClient backs the package-level wrappers used by main and the tray
+var defaultClient = NewClient()
+
+// DefaultClient returns the shared client used by the package-level functions
+func DefaultClient() *Client {
+	return defaultClient
+}
+
+// ConnectQuicServer runs the default client's connection loop (blocks forever)
+func ConnectQuicServer() {
+	defaultClient.Connect()
+}
+
+// DisconnectQuic stops sharing on the default client and disables auto-reconnect
+func DisconnectQuic() {
+	defaultClient.Disconnect()
+}
+
+// ReconnectQuic reconnects the default client and enables auto-reconnect
+func ReconnectQuic() {
+	defaultClient.Reconnect()
+}
+
+// IsConnected returns true if the default client is connected to a QUIC server
+func IsConnected() bool {
+	return defaultClient.IsConnected()
+}
+
+var (
+	tokenRejectedHandler      func()
+	tokenRejectedHandlerMutex sync.RWMutex
+)
+
+// SetTokenRejectedHandler registers a callback run when the server rejects the stored token
+// The tray uses it to reopen the browser login; nil disables it
+func SetTokenRejectedHandler(handler func()) {
+	tokenRejectedHandlerMutex.Lock()
+	tokenRejectedHandler = handler
+	tokenRejectedHandlerMutex.Unlock()
+}
+
+// notifyTokenRejected runs the registered token-rejected handler, if any
+func notifyTokenRejected() {
+	tokenRejectedHandlerMutex.RLock()
+	handler := tokenRejectedHandler
+	tokenRejectedHandlerMutex.RUnlock()
+	if handler != nil {
+		go handler()
+	}
+}
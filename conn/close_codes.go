@@ -0,0 +1,120 @@
+package conn
+
+import (
+	"client/logger"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// QUIC application close codes sent by the server to explain why it closed the connection
+// Transport errors (timeouts, network loss) carry no application code and use normal backoff
+const (
+	closeCodeNormal         quic.ApplicationErrorCode = 0x0
+	closeCodeTooManyClients quic.ApplicationErrorCode = 0x10 // Server at capacity, retry later
+	closeCodeBanned         quic.ApplicationErrorCode = 0x11 // Account or node banned, do not retry
+	closeCodeOutdated       quic.ApplicationErrorCode = 0x12 // Client version no longer supported, do not retry
+
+	// Sent by the client
+	closeCodeProtocolError quic.ApplicationErrorCode = 0x20 // Server violated the protocol (e.g. oversized message)
+)
+
+// Capacity backoff: base delay plus random jitter so rejected clients don't return in lockstep
+const (
+	tooManyClientsBaseDelay = 1 * time.Minute
+	tooManyClientsMaxJitter = 60 * time.Second
+)
+
+// Stream refusal handling: a server that won't grant a stream in time is treated as full
+const (
+	openStreamTimeout  = 10 * time.Second
+	capacityRetryDelay = 1 * time.Second
+)
+
+// isStreamCapacityRefusal reports whether OpenStreamSync failed because the server is at capacity
+// (explicit too-many-clients close, connection refused, or no stream credit granted in time)
+// Other failures are transient and retried against the same selection
+func isStreamCapacityRefusal(err error) bool {
+	if appErr := serverCloseError(err); appErr != nil {
+		return appErr.ErrorCode == closeCodeTooManyClients
+	}
+	var transportErr *quic.TransportError
+	if errors.As(err, &transportErr) && transportErr.ErrorCode == quic.ConnectionRefused {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// serverCloseError extracts a server-initiated application close from a connection error
+// Returns nil for transport errors and locally initiated closes
+func serverCloseError(err error) *quic.ApplicationError {
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) && appErr.Remote {
+		return appErr
+	}
+	return nil
+}
+
+// isTerminalCloseCode reports whether the close code means reconnecting cannot succeed
+func isTerminalCloseCode(code quic.ApplicationErrorCode) bool {
+	return code == closeCodeBanned || code == closeCodeOutdated
+}
+
+// closeCodeMessage returns a user-facing description of a server close
+func closeCodeMessage(appErr *quic.ApplicationError) string {
+	var msg string
+	switch appErr.ErrorCode {
+	case closeCodeTooManyClients:
+		msg = "Server is full"
+	case closeCodeBanned:
+		msg = "Access denied by server"
+	case closeCodeOutdated:
+		msg = "Client version no longer supported - please update"
+	default:
+		msg = fmt.Sprintf("Closed by server (code %d)", appErr.ErrorCode)
+	}
+	if appErr.ErrorMessage != "" {
+		msg += ": " + appErr.ErrorMessage
+	}
+	return msg
+}
+
+// handleServerClose reacts to a server-initiated application close
+// Returns true if the close was handled (stopped or backed off) and the caller should restart the loop
+func (c *Client) handleServerClose(err error) bool {
+	appErr := serverCloseError(err)
+	if appErr == nil || appErr.ErrorCode == closeCodeNormal {
+		return false
+	}
+
+	msg := closeCodeMessage(appErr)
+	logger.Warn("Server closed connection with code %d (%s)", appErr.ErrorCode, appErr.ErrorMessage)
+	logger.GetStatus().IsAuthenticated = false
+	logger.GetStatus().ConnectionUptime = time.Time{}
+	logger.GetStatus().RecordFailure(msg)
+
+	if isTerminalCloseCode(appErr.ErrorCode) {
+		// Retrying would be rejected again: stop until the user starts sharing manually
+		log.Println("Server reported a terminal condition, disabling auto-reconnect")
+		c.autoReconnectMutex.Lock()
+		c.shouldAutoReconnect = false
+		c.stopReason = msg
+		c.autoReconnectMutex.Unlock()
+		return true
+	}
+
+	if appErr.ErrorCode == closeCodeTooManyClients {
+		retryDelay := tooManyClientsBaseDelay + time.Duration(rand.Int63n(int64(tooManyClientsMaxJitter)))
+		log.Printf("Server at capacity, retrying in %v...", retryDelay.Round(time.Second))
+		logger.GetStatus().UpdateStatus("Server full - retrying later")
+		c.backoff(retryDelay)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package conn
+
+import (
+	"client/config"
+	"log"
+	"reflect"
+)
+
+// HandleConfigChange applies a reloaded config to the default client
+// Pass it to config.WatchConfig
+func HandleConfigChange(old, updated config.Config) {
+	defaultClient.HandleConfigChange(old, updated)
+}
+
+// HandleConfigChange reconnects only when settings that affect the server connection changed
+// Everything else (rate limits, allowed ports, buffers, telemetry) is read live and applies as is
+func (c *Client) HandleConfigChange(old, updated config.Config) {
+	if old.HealthAddr != updated.HealthAddr {
+		log.Println("health_addr changed - restart the app to apply it")
+	}
+
+	if !serverSettingsChanged(old, updated) {
+		return
+	}
+	if !c.autoReconnectEnabled() {
+		// Not sharing right now; the new settings apply on the next start
+		return
+	}
+
+	log.Println("Server settings changed, reconnecting to apply them")
+	c.Reconnect()
+}
+
+// serverSettingsChanged reports whether any setting used to pick or dial the server differs
+func serverSettingsChanged(old, updated config.Config) bool {
+	return old.ServerURL != updated.ServerURL ||
+		old.DebugMode != updated.DebugMode ||
+		old.TCPFallback != updated.TCPFallback ||
+		old.TCPFallbackPort != updated.TCPFallbackPort ||
+		old.MemoryProfile != updated.MemoryProfile ||
+		!reflect.DeepEqual(old.APIPaths, updated.APIPaths)
+}
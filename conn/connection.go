@@ -1,70 +1,322 @@
 package conn
 
 import (
+	"client/config"
 	"context"
 	"encoding/base64"
+	"fmt"
 	"log"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // dialWithDNSFallback tries to connect with DNS fallback for better reliability
+// Successful resolutions are cached briefly so busy nodes don't repeat lookups
 func dialWithDNSFallback(address string) (net.Conn, error) {
-	// First attempt with default DNS (5 second timeout)
+	return dialWithTrace(address, nil)
+}
+
+// dialTrace records how a dial resolved its destination (used by TestReachability)
+type dialTrace struct {
+	dnsPath string // "none (IP address)", "cache", "system DNS" or "fallback resolver <addr>"
+}
+
+// setDNSPath records the resolution path when tracing
+func (t *dialTrace) setDNSPath(path string) {
+	if t != nil {
+		t.dnsPath = path
+	}
+}
+
+// dialWithTrace is dialWithDNSFallback, recording the resolution path in trace when non-nil
+func dialWithTrace(address string, trace *dialTrace) (net.Conn, error) {
+	// 5 second timeout per connection attempt
 	dialer := &net.Dialer{
 		Timeout: 5 * time.Second,
 	}
+	bindIP := config.GetBindAddr()
+	if bindIP != nil {
+		// Egress from the configured source address (bind_addr)
+		dialer.LocalAddr = &net.TCPAddr{IP: bindIP}
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	conn, err := dialer.DialContext(ctx, "tcp", address)
-	if err == nil {
-		return conn, nil
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		// Not host:port or already an IP - nothing to resolve
+		trace.setDNSPath("none (IP address)")
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	// Use cached resolution if available
+	if ips, ok := resolvedHosts.get(host); ok {
+		if usable := matchBindFamily(ips, bindIP); len(usable) > 0 {
+			conn, err := dialResolved(ctx, dialer, usable, port)
+			if err == nil {
+				trace.setDNSPath("cache")
+				return conn, nil
+			}
+		}
+		// Cached addresses no longer work (or none fit bind_addr), resolve again
+		resolvedHosts.remove(host)
+	}
+
+	ips, source, err := resolveHost(ctx, host)
+	trace.setDNSPath(source)
+	if err != nil {
+		return nil, err
+	}
+	resolvedHosts.put(host, ips)
+
+	usable := matchBindFamily(ips, bindIP)
+	if len(usable) == 0 {
+		return nil, fmt.Errorf("destination has no address in the family of bind_addr %s", bindIP)
+	}
+	return dialResolved(ctx, dialer, usable, port)
+}
+
+// matchBindFamily keeps the addresses a socket bound to bindIP can reach (same IPv4/IPv6 family)
+// All addresses are kept when no bind address is set
+func matchBindFamily(ips []string, bindIP net.IP) []string {
+	if bindIP == nil {
+		return ips
+	}
+	wantV4 := bindIP.To4() != nil
+	matched := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if parsed := net.ParseIP(ip); parsed != nil && (parsed.To4() != nil) == wantV4 {
+			matched = append(matched, ip)
+		}
 	}
+	return matched
+}
 
-	// If DNS resolution failed, try with Google DNS
-	if strings.Contains(err.Error(), "no such host") || strings.Contains(err.Error(), "Temporary failure") {
-		log.Printf("DNS resolution failed with system DNS, trying with custom resolver...")
+// resolveHost resolves host with system DNS, then the configured fallback resolvers
+// Also returns which resolver answered (or was tried last on failure)
+func resolveHost(ctx context.Context, host string) ([]string, string, error) {
+	source := "system DNS"
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err == nil && len(ips) > 0 {
+		return ips, source, nil
+	}
 
-		// Use custom DNS resolver (Google DNS 8.8.8.8)
-		resolver := &net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := net.Dialer{Timeout: 3 * time.Second}
-				return d.DialContext(ctx, network, "8.8.8.8:53")
-			},
+	// If DNS resolution failed, try the configured fallback resolvers in order
+	if err != nil && (strings.Contains(err.Error(), "no such host") || strings.Contains(err.Error(), "Temporary failure")) {
+		resolvers := config.GetFallbackDNS()
+		if len(resolvers) == 0 {
+			return nil, source, err
 		}
 
-		// Extract host and port from address
-		host, port, splitErr := net.SplitHostPort(address)
-		if splitErr != nil {
-			return nil, splitErr
+		log.Printf("DNS resolution failed with system DNS, trying %d fallback resolver(s)...", len(resolvers))
+
+		for _, dnsServer := range resolvers {
+			source = "fallback resolver " + dnsServer
+			fallbackIPs, resolveErr := lookupHostVia(ctx, dnsServer, host)
+			if resolveErr != nil || len(fallbackIPs) == 0 {
+				// Privacy: resolver errors include the hostname, so don't log them
+				log.Printf("Fallback resolver %s could not resolve destination", dnsServer)
+				continue
+			}
+			return fallbackIPs, source, nil
+		}
+	}
+
+	if err == nil {
+		err = fmt.Errorf("no addresses found")
+	}
+	return nil, source, err // Return original error
+}
+
+// happyEyeballsDelay is the stagger between parallel connection attempts (RFC 8305)
+const happyEyeballsDelay = 250 * time.Millisecond
+
+// dialResolved races connections to the resolved addresses (RFC 8305 happy eyeballs)
+// Attempts start happyEyeballsDelay apart (or immediately after a failure);
+// the first successful connection wins and the rest are cancelled
+func dialResolved(ctx context.Context, dialer *net.Dialer, ips []string, port string) (net.Conn, error) {
+	ips = interleaveAddressFamilies(ips)
+	if len(ips) == 1 {
+		return dialer.DialContext(ctx, "tcp", net.JoinHostPort(ips[0], port))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan dialResult, len(ips))
+
+	next := 0
+	pending := 0
+	startNext := func() {
+		addr := net.JoinHostPort(ips[next], port)
+		next++
+		pending++
+		go func() {
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			results <- dialResult{conn: conn, err: err}
+		}()
+	}
+
+	startNext()
+	stagger := time.NewTimer(happyEyeballsDelay)
+	defer stagger.Stop()
+
+	var lastErr error
+	for pending > 0 {
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				// Winner - cancel the others and close any late successes
+				cancel()
+				go func(remaining int) {
+					for i := 0; i < remaining; i++ {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}
+				}(pending)
+				return res.conn, nil
+			}
+			lastErr = res.err
+			// Failed attempt - start the next one right away
+			if next < len(ips) {
+				startNext()
+				stagger.Reset(happyEyeballsDelay)
+			}
+		case <-stagger.C:
+			if next < len(ips) {
+				startNext()
+				stagger.Reset(happyEyeballsDelay)
+			}
 		}
+	}
+
+	return nil, lastErr
+}
 
-		// Resolve with custom DNS
-		ips, resolveErr := resolver.LookupHost(ctx, host)
-		if resolveErr != nil {
-			return nil, err // Return original error
+// interleaveAddressFamilies orders addresses IPv6, IPv4, IPv6, ... (RFC 8305 section 4)
+func interleaveAddressFamilies(ips []string) []string {
+	var v6, v4 []string
+	for _, ip := range ips {
+		if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+			v6 = append(v6, ip)
+		} else {
+			v4 = append(v4, ip)
 		}
+	}
+	if len(v6) == 0 || len(v4) == 0 {
+		return ips
+	}
 
-		if len(ips) > 0 {
-			// Try to connect with resolved IP
-			resolvedAddr := net.JoinHostPort(ips[0], port)
-			return dialer.DialContext(ctx, "tcp", resolvedAddr)
+	ordered := make([]string, 0, len(ips))
+	for i := 0; i < len(v6) || i < len(v4); i++ {
+		if i < len(v6) {
+			ordered = append(ordered, v6[i])
+		}
+		if i < len(v4) {
+			ordered = append(ordered, v4[i])
 		}
 	}
+	return ordered
+}
+
+// lookupHostVia resolves host using a specific DNS server (host:port)
+func lookupHostVia(ctx context.Context, dnsServer, host string) ([]string, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{Timeout: 3 * time.Second}
+			return d.DialContext(ctx, network, dnsServer)
+		},
+	}
+	return resolver.LookupHost(ctx, host)
+}
+
+// connectTarget returns the address to dial for a connect message
+// Addr wins when present; otherwise the optional Host (host:port) is used
+func connectTarget(msg Message) string {
+	if msg.Addr != "" {
+		return msg.Addr
+	}
+	return msg.Host
+}
+
+// connectDestination returns the destination max_conns_per_host and logs refer to
+// The original hostname wins when present, since Addr may be an IP the server resolved it to
+func connectDestination(msg Message) string {
+	if msg.Host != "" {
+		return destinationHost(msg.Host)
+	}
+	return destinationHost(msg.Addr)
+}
 
-	return nil, err
+// targetPortAllowed checks the destination port against the allowed_ports config
+func targetPortAllowed(target string) bool {
+	_, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return config.IsPortAllowed(0) // No port: only allowed when there is no allowlist
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return config.IsPortAllowed(0)
+	}
+	return config.IsPortAllowed(port)
 }
 
-func handleConnect(msg Message) {
-	conn, err := dialWithDNSFallback(msg.Addr)
+// handleConnect dials the destination for a connect received on ws (nil = primary stream)
+func (c *Client) handleConnect(msg Message, ws *workerStream) {
+	// Every error exit goes through abort, which releases whatever has been set up so far
+	// notify is false when the server stream itself failed and there's no one left to tell
+	var aborted, registered bool
+	var hostSlot string
+	abort := func(notify bool) {
+		if aborted {
+			return
+		}
+		aborted = true
+		if !registered {
+			c.releaseHostSlot(hostSlot) // Once registered, removing the connection releases it
+		}
+		if notify {
+			c.sendCloseMessageVia(ws, msg.ID) // Also removes the connection if registered
+		} else {
+			c.removeConnection(msg.ID)
+		}
+	}
+
+	// Dial Addr, but apply policy to the hostname the user asked for
+	target := connectTarget(msg)
+	destination := connectDestination(msg)
+	if !targetPortAllowed(target) {
+		// Privacy: the destination is redacted unless verbose_logging is on
+		log.Printf("Refused connection to %s: port outside allowed_ports", config.RedactPersonal(destination))
+		abort(true)
+		return
+	}
+
+	// Abuse control: cap concurrent connections to one destination (max_conns_per_host)
+	hostSlot, ok := c.acquireHostSlot(destination)
+	if !ok {
+		log.Printf("Refused connection to %s: destination is at max_conns_per_host", config.RedactPersonal(destination))
+		abort(true)
+		return
+	}
+
+	conn, err := dialWithDNSFallback(target)
 	if err != nil || conn == nil {
-		// Privacy: Don't log destination address to protect proxy user privacy
-		log.Printf("Failed to establish connection: %v", err)
-		sendCloseMessage(msg.ID)
+		log.Printf("Failed to establish connection to %s: %v", config.RedactPersonal(destination), err)
+		if conn != nil {
+			conn.Close()
+		}
+		abort(true)
 		return
 	}
 
@@ -78,12 +330,15 @@ func handleConnect(msg Message) {
 		tcpConn.SetKeepAlivePeriod(30 * time.Second) // Keepalive every 30 seconds
 	}
 
-	dataChan := make(chan []byte, 10000) // Increased from 100 to 10000 for better throughput
-	cc := &Connection{conn: conn, dataChan: dataChan}
+	dataChan := make(chan []byte, config.GetDataChannelBuffer()) // Configurable via data_channel_buffer
+	cc := &Connection{conn: conn, dataChan: dataChan, worker: ws, hostSlot: hostSlot, opened: time.Now()}
 
-	clientMutex.Lock()
-	clientConns[msg.ID] = cc
-	clientMutex.Unlock()
+	// Registered straight after the dial so abort owns the socket from here on
+	c.clientMutex.Lock()
+	c.clientConns[msg.ID] = cc
+	c.updateActiveConns()
+	c.clientMutex.Unlock()
+	registered = true
 
 	// Send confirmation to server that connection is established
 	confirmMsg := &Message{
@@ -91,23 +346,23 @@ func handleConnect(msg Message) {
 		ID:   msg.ID,
 		Data: "",
 	}
-	if err := sendMessage(confirmMsg); err != nil {
+	if err := c.sendMessageVia(ws, confirmMsg); err != nil {
+		// Server stream is gone, so there's no one to tell; just tear down locally
 		log.Printf("Failed to send connect confirmation: %v", err)
-		conn.Close()
+		abort(false)
 		return
 	}
 
 	// Write initial data if any
 	if msg.Data != "" {
 		data, _ := base64.StdEncoding.DecodeString(msg.Data)
-		_, err = conn.Write(data)
-		if err != nil {
+		if _, err := conn.Write(data); err != nil {
 			log.Printf("Failed to write initial data: %v", err)
-			sendCloseMessage(msg.ID)
+			abort(true)
 			return
 		}
 	}
 
-	go relayFromConnToQuic(cc, msg.ID)
-	go relayFromChanToConn(cc, msg.ID)
+	// Relays take over teardown from here
+	c.startRelays(cc, msg.ID)
 }
@@ -0,0 +1,129 @@
+package conn
+
+import (
+	"client/config"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/quic-go/quic-go"
+)
+
+// ConnectivityStage identifies which step of a connectivity test failed
+type ConnectivityStage string
+
+const (
+	StageDial   ConnectivityStage = "dial"   // Reaching the server (network, firewall, UDP)
+	StageStream ConnectivityStage = "stream" // Opening the message stream (server capacity)
+	StageAuth   ConnectivityStage = "auth"   // Authenticating (login, token, maintenance)
+)
+
+// ConnectivityError is returned by TestConnectivity when a step fails
+type ConnectivityError struct {
+	Stage  ConnectivityStage
+	Server string
+	Err    error
+	reason string // User-facing explanation
+}
+
+func (e *ConnectivityError) Error() string {
+	return fmt.Sprintf("connectivity test failed at %s (%s): %v", e.Stage, e.Server, e.Err)
+}
+
+func (e *ConnectivityError) Unwrap() error {
+	return e.Err
+}
+
+// Reason returns a short explanation suitable for a notification
+func (e *ConnectivityError) Reason() string {
+	return e.reason
+}
+
+// TestConnectivity does a one-shot dial and auth handshake against the server that sharing would use
+// Nothing is relayed and the default client's state is untouched; the test connection is closed on return
+func TestConnectivity(ctx context.Context) error {
+	serverAddr := getForcedServer()
+	if serverAddr == "" {
+		serverAddr = GetOptimalServer(getAPIURL(), "us.vyx.network:8443")
+		if serverAddr == "" {
+			return &ConnectivityError{Stage: StageDial, Err: errAllServersBusy, reason: allServersBusyStatus}
+		}
+	}
+	tlsConf := buildTLSConfig(serverAddr)
+
+	var conn serverConn
+	var stream serverStream
+
+	quicConn, err := quic.DialAddr(ctx, serverAddr, tlsConf, buildQUICConfig(config.GetMemoryProfile()))
+	if err != nil && isQUICTimeout(err) && config.GetTCPFallbackEnabled() {
+		// Same fallback the connect loop would end up using on a UDP-hostile network
+		tcpConn, tcpErr := dialTCPFallback(ctx, serverAddr, tlsConf, config.GetTCPFallbackPort())
+		if tcpErr == nil {
+			conn, stream, err = tcpConn, tcpConn, nil
+		}
+	} else if err == nil {
+		streamCtx, cancel := context.WithTimeout(ctx, openStreamTimeout)
+		quicStream, streamErr := quicConn.OpenStreamSync(streamCtx)
+		cancel()
+		if streamErr != nil {
+			quicConn.CloseWithError(0, "connectivity test")
+			return &ConnectivityError{Stage: StageStream, Server: serverAddr, Err: streamErr, reason: streamFailureReason(streamErr)}
+		}
+		conn, stream = quicConn, quicStream
+	}
+	if err != nil {
+		return &ConnectivityError{Stage: StageDial, Server: serverAddr, Err: err, reason: dialFailureReason(serverAddr, err)}
+	}
+	defer conn.CloseWithError(0, "connectivity test")
+
+	// A throwaway client keeps the probe's negotiated state away from the real one
+	probe := NewClient()
+	if err := probe.authenticateWithServer(stream); err != nil {
+		return &ConnectivityError{Stage: StageAuth, Server: serverAddr, Err: err, reason: authFailureReason(err)}
+	}
+
+	log.Printf("Connectivity test passed (%s)", serverAddr)
+	return nil
+}
+
+// dialFailureReason explains why the server couldn't be reached
+func dialFailureReason(serverAddr string, err error) string {
+	if appErr := serverCloseError(err); appErr != nil {
+		return closeCodeMessage(appErr)
+	}
+	if isQUICTimeout(err) && tcpReachable(serverAddr, config.GetTCPFallbackPort()) {
+		return udpBlockedStatus
+	}
+	if behindCaptivePortal() {
+		return captivePortalStatus
+	}
+	return "Can't reach the Vyx server - check your internet connection and firewall"
+}
+
+// streamFailureReason explains why the server didn't accept a stream
+func streamFailureReason(err error) string {
+	if appErr := serverCloseError(err); appErr != nil {
+		return closeCodeMessage(appErr)
+	}
+	if isStreamCapacityRefusal(err) {
+		return "Server is full - try again in a few minutes"
+	}
+	return "Server accepted the connection but didn't respond"
+}
+
+// authFailureReason explains why authentication failed
+func authFailureReason(err error) string {
+	var maintenanceErr *maintenanceError
+	var serverErr *serverAuthError
+	switch {
+	case !config.IsLoggedIn():
+		return "Not logged in - click Login first"
+	case errors.As(err, &maintenanceErr):
+		return "Server maintenance - try again shortly"
+	case errors.As(err, &serverErr):
+		return "Login rejected: " + serverErr.message
+	default:
+		return "Authentication failed: " + err.Error()
+	}
+}
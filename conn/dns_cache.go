@@ -0,0 +1,75 @@
+package conn
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	dnsCacheTTL        = 60 * time.Second // Short TTL keeps cached answers reasonably fresh
+	dnsCacheMaxEntries = 4096             // Bound memory on nodes proxying to many hosts
+)
+
+type dnsCacheEntry struct {
+	ips     []string
+	expires time.Time
+}
+
+// dnsCache is a small in-memory cache of successful hostname resolutions
+type dnsCache struct {
+	mu      sync.Mutex
+	entries map[string]dnsCacheEntry
+}
+
+var resolvedHosts = &dnsCache{entries: make(map[string]dnsCacheEntry)}
+
+// get returns cached IPs for host if present and not expired
+func (c *dnsCache) get(host string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[host]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, host)
+		return nil, false
+	}
+	return entry.ips, true
+}
+
+// put stores a successful resolution for host
+func (c *dnsCache) put(host string, ips []string) {
+	if len(ips) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= dnsCacheMaxEntries {
+		c.pruneLocked()
+	}
+	c.entries[host] = dnsCacheEntry{ips: ips, expires: time.Now().Add(dnsCacheTTL)}
+}
+
+// remove drops a cached entry (e.g. when every cached address failed to connect)
+func (c *dnsCache) remove(host string) {
+	c.mu.Lock()
+	delete(c.entries, host)
+	c.mu.Unlock()
+}
+
+// pruneLocked removes expired entries, or everything if the cache is still full
+func (c *dnsCache) pruneLocked() {
+	now := time.Now()
+	for host, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, host)
+		}
+	}
+	if len(c.entries) >= dnsCacheMaxEntries {
+		c.entries = make(map[string]dnsCacheEntry)
+	}
+}
@@ -0,0 +1,75 @@
+package conn
+
+import (
+	"client/logger"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lines returned by GET /logs: ?n= picks how many, up to maxLogLines
+const (
+	defaultLogLines = 100
+	maxLogLines     = 500
+)
+
+// StartHealthServer serves GET /healthz on addr for container/orchestration healthchecks
+// Returns 200 when connected and authenticated, 503 otherwise
+// GET /status returns the full status text (including reconnect history); GET /logs the most recent log lines
+func StartHealthServer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if CurrentState() == StateConnected {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("ok\n"))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(logger.GetStatus().Status + "\n"))
+	})
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		// Human-readable status including the reconnect history, for support and diagnosis
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(logger.GetStatus().GetStatusText() + "\n"))
+	})
+
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		// Recent log lines in any mode (read from memory when there's no log file)
+		n := defaultLogLines
+		if v, err := strconv.Atoi(r.URL.Query().Get("n")); err == nil && v > 0 {
+			n = min(v, maxLogLines)
+		}
+		lines, err := logger.TailLogs(n)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	})
+
+	server := &http.Server{
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Health server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Health endpoint listening on http://%s/healthz", listener.Addr())
+	return nil
+}
@@ -0,0 +1,49 @@
+package conn
+
+import (
+	"client/config"
+	"net"
+	"strings"
+)
+
+// destinationHost is the key max_conns_per_host counts by: the target's host, lowercased and without a trailing dot
+// Counted before dialing, so refused connects never reach the destination
+func destinationHost(target string) string {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		host = target
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// acquireHostSlot reserves a connection slot for host under max_conns_per_host
+// Returns the key to release later ("" when unlimited, so nothing is tracked) and false when host is at the limit
+func (c *Client) acquireHostSlot(host string) (string, bool) {
+	limit := config.GetMaxConnsPerHost()
+	if limit <= 0 {
+		return "", true
+	}
+
+	c.hostMutex.Lock()
+	defer c.hostMutex.Unlock()
+	if c.hostConns[host] >= limit {
+		return "", false
+	}
+	c.hostConns[host]++
+	return host, true
+}
+
+// releaseHostSlot frees a slot taken by acquireHostSlot; a "" key is a no-op
+func (c *Client) releaseHostSlot(host string) {
+	if host == "" {
+		return
+	}
+
+	c.hostMutex.Lock()
+	defer c.hostMutex.Unlock()
+	if c.hostConns[host] <= 1 {
+		delete(c.hostConns, host)
+		return
+	}
+	c.hostConns[host]--
+}
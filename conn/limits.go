@@ -0,0 +1,45 @@
+package conn
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// Message size limits protecting against memory exhaustion from a buggy or malicious server
+const (
+	// maxMessageSize caps a single JSON message read from the server
+	maxMessageSize = 4 * 1024 * 1024
+	// maxDataSize caps the decoded payload of a single data message
+	maxDataSize = 2 * 1024 * 1024
+)
+
+// maxEncodedDataSize is the base64 length of a maxDataSize payload
+var maxEncodedDataSize = base64.StdEncoding.EncodedLen(maxDataSize)
+
+// errMessageTooLarge is a protocol error: the server sent an oversized message
+var errMessageTooLarge = errors.New("message exceeds maximum size")
+
+// messageLimitReader fails once more than the allowed bytes are read since the last reset
+// Reset before each decode so no single message can pull in more than the limit
+// (plus whatever the decoder had already buffered from the previous read)
+type messageLimitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *messageLimitReader) reset(limit int64) {
+	l.remaining = limit
+}
+
+func (l *messageLimitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, errMessageTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
@@ -2,38 +2,114 @@ package conn
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
+	"sync"
+)
+
+// Protocol versions negotiated during auth
+// The client advertises ProtocolVersion; servers that don't echo a version speak version 1
+const (
+	ProtocolVersionJSON = 1 // Newline-delimited JSON messages with base64 data
+	ProtocolVersion     = ProtocolVersionJSON
 )
 
 // Message types (1 byte)
 const (
-	MsgTypeAuth        = 0
-	MsgTypeAuthSuccess = 1
-	MsgTypeError       = 2
-	MsgTypeConnect     = 3
-	MsgTypeConnected   = 4
-	MsgTypeData        = 5
-	MsgTypeClose       = 6
-	MsgTypePing        = 7
-	MsgTypePong        = 8
-	MsgTypeAddress     = 9
-	MsgTypeUIDRegister = 10
+	MsgTypeAuth           = 0
+	MsgTypeAuthSuccess    = 1
+	MsgTypeError          = 2
+	MsgTypeConnect        = 3
+	MsgTypeConnected      = 4
+	MsgTypeData           = 5
+	MsgTypeClose          = 6
+	MsgTypePing           = 7
+	MsgTypePong           = 8
+	MsgTypeAddress        = 9
+	MsgTypeUIDRegister    = 10
+	MsgTypeMaintenance    = 11
+	MsgTypeHalfClose      = 12
+	MsgTypeServerShutdown = 13
+)
+
+// msgFlagHasHost is set on the type byte when a Host field follows Addr.
+// Messages without a host are encoded exactly as before.
+const msgFlagHasHost = 0x80
+
+// FrameLimits caps the length fields accepted by ReadBinaryMessage
+// Lengths are validated before allocating so a corrupt or hostile frame can't force a huge allocation
+type FrameLimits struct {
+	MaxIDLen   uint16
+	MaxAddrLen uint16
+	MaxHostLen uint16
+	MaxDataLen uint32
+}
+
+// DefaultFrameLimits are generous for real traffic (IDs are short, addresses are host:port)
+var DefaultFrameLimits = FrameLimits{
+	MaxIDLen:   256,
+	MaxAddrLen: 1024,
+	MaxHostLen: 1024,
+	MaxDataLen: maxDataSize,
+}
+
+var (
+	frameLimits      = DefaultFrameLimits
+	frameLimitsMutex sync.RWMutex
 )
 
+// SetFrameLimits replaces the limits used by ReadBinaryMessage
+// Zero fields keep their default value
+func SetFrameLimits(limits FrameLimits) {
+	if limits.MaxIDLen == 0 {
+		limits.MaxIDLen = DefaultFrameLimits.MaxIDLen
+	}
+	if limits.MaxAddrLen == 0 {
+		limits.MaxAddrLen = DefaultFrameLimits.MaxAddrLen
+	}
+	if limits.MaxHostLen == 0 {
+		limits.MaxHostLen = DefaultFrameLimits.MaxHostLen
+	}
+	if limits.MaxDataLen == 0 {
+		limits.MaxDataLen = DefaultFrameLimits.MaxDataLen
+	}
+	frameLimitsMutex.Lock()
+	frameLimits = limits
+	frameLimitsMutex.Unlock()
+}
+
+// getFrameLimits returns the currently configured frame limits
+func getFrameLimits() FrameLimits {
+	frameLimitsMutex.RLock()
+	defer frameLimitsMutex.RUnlock()
+	return frameLimits
+}
+
 // BinaryMessage represents a message in binary format (no JSON, no base64)
 type BinaryMessage struct {
 	Type byte
 	ID   string
 	Addr string
+	Host string // Optional original hostname (CONNECT/SNI) for the destination
 	Data []byte // Raw bytes instead of base64 string
 }
 
 // WriteBinaryMessage writes a message in binary format to a writer
 // Format: [1 byte: type][2 bytes: ID len][ID bytes][2 bytes: addr len][addr bytes][4 bytes: data len][data bytes]
+// If Host is set, the type byte carries msgFlagHasHost and [2 bytes: host len][host bytes] follows addr
 func WriteBinaryMessage(w io.Writer, msg *BinaryMessage) error {
+	// Checked before writing anything: a truncated length would corrupt the frame
+	if limit := getFrameLimits().MaxHostLen; len(msg.Host) > int(limit) {
+		return fmt.Errorf("host length %d exceeds limit %d: %w", len(msg.Host), limit, errMessageTooLarge)
+	}
+
 	// Write message type
-	if err := binary.Write(w, binary.BigEndian, msg.Type); err != nil {
+	msgType := msg.Type
+	if msg.Host != "" {
+		msgType |= msgFlagHasHost
+	}
+	if err := binary.Write(w, binary.BigEndian, msgType); err != nil {
 		return fmt.Errorf("failed to write message type: %w", err)
 	}
 
@@ -59,6 +135,17 @@ func WriteBinaryMessage(w io.Writer, msg *BinaryMessage) error {
 		}
 	}
 
+	// Write Host length and Host (only when present)
+	if msg.Host != "" {
+		hostLen := uint16(len(msg.Host))
+		if err := binary.Write(w, binary.BigEndian, hostLen); err != nil {
+			return fmt.Errorf("failed to write host length: %w", err)
+		}
+		if _, err := w.Write([]byte(msg.Host)); err != nil {
+			return fmt.Errorf("failed to write host: %w", err)
+		}
+	}
+
 	// Write Data length and Data (raw bytes, no base64!)
 	dataLen := uint32(len(msg.Data))
 	if err := binary.Write(w, binary.BigEndian, dataLen); err != nil {
@@ -76,17 +163,23 @@ func WriteBinaryMessage(w io.Writer, msg *BinaryMessage) error {
 // ReadBinaryMessage reads a message in binary format from a reader
 func ReadBinaryMessage(r io.Reader) (*BinaryMessage, error) {
 	msg := &BinaryMessage{}
+	limits := getFrameLimits()
 
 	// Read message type
 	if err := binary.Read(r, binary.BigEndian, &msg.Type); err != nil {
 		return nil, fmt.Errorf("failed to read message type: %w", err)
 	}
+	hasHost := msg.Type&msgFlagHasHost != 0
+	msg.Type &^= msgFlagHasHost
 
 	// Read ID length and ID
 	var idLen uint16
 	if err := binary.Read(r, binary.BigEndian, &idLen); err != nil {
 		return nil, fmt.Errorf("failed to read ID length: %w", err)
 	}
+	if idLen > limits.MaxIDLen {
+		return nil, fmt.Errorf("ID length %d exceeds limit %d: %w", idLen, limits.MaxIDLen, errMessageTooLarge)
+	}
 	if idLen > 0 {
 		idBytes := make([]byte, idLen)
 		if _, err := io.ReadFull(r, idBytes); err != nil {
@@ -100,6 +193,9 @@ func ReadBinaryMessage(r io.Reader) (*BinaryMessage, error) {
 	if err := binary.Read(r, binary.BigEndian, &addrLen); err != nil {
 		return nil, fmt.Errorf("failed to read addr length: %w", err)
 	}
+	if addrLen > limits.MaxAddrLen {
+		return nil, fmt.Errorf("addr length %d exceeds limit %d: %w", addrLen, limits.MaxAddrLen, errMessageTooLarge)
+	}
 	if addrLen > 0 {
 		addrBytes := make([]byte, addrLen)
 		if _, err := io.ReadFull(r, addrBytes); err != nil {
@@ -108,11 +204,32 @@ func ReadBinaryMessage(r io.Reader) (*BinaryMessage, error) {
 		msg.Addr = string(addrBytes)
 	}
 
+	// Read Host length and Host (only when flagged)
+	if hasHost {
+		var hostLen uint16
+		if err := binary.Read(r, binary.BigEndian, &hostLen); err != nil {
+			return nil, fmt.Errorf("failed to read host length: %w", err)
+		}
+		if hostLen > limits.MaxHostLen {
+			return nil, fmt.Errorf("host length %d exceeds limit %d: %w", hostLen, limits.MaxHostLen, errMessageTooLarge)
+		}
+		if hostLen > 0 {
+			hostBytes := make([]byte, hostLen)
+			if _, err := io.ReadFull(r, hostBytes); err != nil {
+				return nil, fmt.Errorf("failed to read host: %w", err)
+			}
+			msg.Host = string(hostBytes)
+		}
+	}
+
 	// Read Data length and Data
 	var dataLen uint32
 	if err := binary.Read(r, binary.BigEndian, &dataLen); err != nil {
 		return nil, fmt.Errorf("failed to read data length: %w", err)
 	}
+	if dataLen > limits.MaxDataLen {
+		return nil, fmt.Errorf("data length %d exceeds limit %d: %w", dataLen, limits.MaxDataLen, errMessageTooLarge)
+	}
 	if dataLen > 0 {
 		msg.Data = make([]byte, dataLen)
 		if _, err := io.ReadFull(r, msg.Data); err != nil {
@@ -128,6 +245,7 @@ func MessageToBinary(m *Message) *BinaryMessage {
 	bm := &BinaryMessage{
 		ID:   m.ID,
 		Addr: m.Addr,
+		Host: m.Host,
 	}
 
 	// Map type string to byte
@@ -148,6 +266,7 @@ func MessageToBinary(m *Message) *BinaryMessage {
 		bm.Data = []byte(m.Data)
 	case "close":
 		bm.Type = MsgTypeClose
+		bm.Data = []byte(m.Data) // Optional close reason (closeReasonReset)
 	case "ping":
 		bm.Type = MsgTypePing
 	case "pong":
@@ -156,6 +275,12 @@ func MessageToBinary(m *Message) *BinaryMessage {
 		bm.Type = MsgTypeAddress
 	case "uid-register":
 		bm.Type = MsgTypeUIDRegister
+	case "maintenance":
+		bm.Type = MsgTypeMaintenance
+	case "half_close":
+		bm.Type = MsgTypeHalfClose
+	case "server_shutdown":
+		bm.Type = MsgTypeServerShutdown
 	}
 
 	return bm
@@ -166,6 +291,7 @@ func BinaryToMessage(bm *BinaryMessage) *Message {
 	m := &Message{
 		ID:   bm.ID,
 		Addr: bm.Addr,
+		Host: bm.Host,
 		Data: string(bm.Data),
 	}
 
@@ -193,7 +319,36 @@ func BinaryToMessage(bm *BinaryMessage) *Message {
 		m.Type = "address"
 	case MsgTypeUIDRegister:
 		m.Type = "uid-register"
+	case MsgTypeMaintenance:
+		m.Type = "maintenance"
+	case MsgTypeHalfClose:
+		m.Type = "half_close"
+	case MsgTypeServerShutdown:
+		m.Type = "server_shutdown"
 	}
 
 	return m
 }
+
+// parseAuthSuccess extracts the identity and negotiated protocol version from auth_success Data
+// Data is either plain text (older servers, version 1) or JSON like {"user": "...", "protocol_version": 1}
+func parseAuthSuccess(data string) (string, int) {
+	var payload struct {
+		User            string `json:"user"`
+		Email           string `json:"email"`
+		ProtocolVersion int    `json:"protocol_version"`
+	}
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		return data, ProtocolVersionJSON
+	}
+
+	identity := payload.User
+	if identity == "" {
+		identity = payload.Email
+	}
+	version := payload.ProtocolVersion
+	if version <= 0 {
+		version = ProtocolVersionJSON
+	}
+	return identity, version
+}
@@ -3,16 +3,22 @@ package conn
 import (
 	"client/config"
 	"client/logger"
+	"client/platform"
+	"client/version"
 	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/quic-go/quic-go"
@@ -22,23 +28,47 @@ type Message struct {
 	Type string `json:"type"`
 	ID   string `json:"id"`
 	Addr string `json:"addr,omitempty"`
+	// Host is the original hostname requested by the proxy user (CONNECT/SNI),
+	// sent alongside Addr when the server has already resolved the destination
+	Host string `json:"host,omitempty"`
 	Data string `json:"data,omitempty"`
 }
 
 type Connection struct {
 	conn     net.Conn
 	dataChan chan []byte
+	dataOnce sync.Once
+	// readDone is set once the destination has sent EOF (half-close sent to server)
+	readDone atomic.Bool
+	// writeDone is set once the server has half-closed (no more data for the destination)
+	writeDone atomic.Bool
+	// worker is the stream the connect arrived on; replies go back on it (nil = primary stream)
+	worker *workerStream
+	// hostSlot is the max_conns_per_host key released when the connection is removed ("" if untracked)
+	hostSlot string
+	// opened is when the destination was connected, for the duration histogram
+	opened time.Time
 }
 
-var (
-	quicConn            *quic.Conn
-	quicStream          *quic.Stream
-	quicMutex           sync.Mutex
-	clientConns         = make(map[string]*Connection)
-	clientMutex         sync.RWMutex        // Changed to RWMutex for better read performance
-	shouldAutoReconnect bool         = true // Controls whether client should auto-reconnect
-	autoReconnectMutex  sync.RWMutex
-)
+// closeData closes dataChan exactly once, whichever close path gets there first
+func (cc *Connection) closeData() {
+	cc.dataOnce.Do(func() { close(cc.dataChan) })
+}
+
+// softCloseTimeout bounds how long queued data may take to flush after the server closes a connection
+const softCloseTimeout = 10 * time.Second
+
+// softClose stops accepting data from the server but lets relayFromChanToConn
+// flush whatever is still queued before it closes the destination
+func (cc *Connection) softClose() {
+	if cc.writeDone.Load() {
+		// Already flushed and half-closed, nothing left to drain
+		cc.conn.Close()
+		return
+	}
+	cc.conn.SetWriteDeadline(time.Now().Add(softCloseTimeout))
+	cc.closeData()
+}
 
 /* Retry Strategy:
 - Attempt 1: Immediate (no delay)
@@ -55,19 +85,17 @@ Special cases:
 
 // buildTLSConfig creates TLS configuration based on server address
 func buildTLSConfig(serverAddr string) *tls.Config {
+	requireTLS13 := config.GetRequireTLS13()
 	config := &tls.Config{
 		NextProtos: []string{"vyx-proxy"},
 		MinVersion: tls.VersionTLS12, // Minimum TLS 1.2 for security
 	}
 
-	// Extract hostname from address
-	host := serverAddr
-	if strings.Contains(serverAddr, ":") {
-		host, _, _ = net.SplitHostPort(serverAddr)
-	}
+	// Extract hostname from address (IPv6 literals come back without brackets)
+	host := serverHost(serverAddr)
 
-	// Development mode: localhost or 127.0.0.1
-	if host == "localhost" || host == "127.0.0.1" {
+	// Development mode: localhost or loopback
+	if host == "localhost" || host == "127.0.0.1" || host == "::1" {
 		log.Println("Development mode: Using InsecureSkipVerify for localhost")
 		config.InsecureSkipVerify = true
 	} else {
@@ -75,11 +103,49 @@ func buildTLSConfig(serverAddr string) *tls.Config {
 		log.Printf("Production mode: Verifying TLS certificate for %s", host)
 		config.ServerName = host
 		config.InsecureSkipVerify = false
+		if requireTLS13 {
+			// Opt-in hardening: refuse servers that only offer TLS 1.2
+			config.MinVersion = tls.VersionTLS13
+		}
 	}
 
 	return config
 }
 
+// describeTLS summarizes the negotiated TLS parameters for the status display (e.g. "TLS 1.3, TLS_AES_128_GCM_SHA256")
+func describeTLS(state tls.ConnectionState) string {
+	info := fmt.Sprintf("%s, %s", tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite))
+	if state.Version < tls.VersionTLS13 {
+		log.Printf("Warning: Server negotiated %s (TLS 1.3 expected)", tls.VersionName(state.Version))
+	}
+	return info
+}
+
+// buildQUICConfig returns QUIC settings for the selected memory profile
+// Windows start at the initial size and QUIC auto-tunes them up to the max as throughput demands
+func buildQUICConfig(profile string) *quic.Config {
+	// Configure QUIC with longer timeouts for stable connections
+	// PERFORMANCE: Tuned for high-latency (200ms RTT) connections to server
+	quicConfig := &quic.Config{
+		MaxIdleTimeout:                 15 * time.Minute, // Keep connections alive for 15 minutes idle
+		KeepAlivePeriod:                30 * time.Second, // Send keepalive every 30 seconds
+		InitialStreamReceiveWindow:     4 * 1024 * 1024,  // 4 MB initial stream window (high BDP)
+		MaxStreamReceiveWindow:         16 * 1024 * 1024, // 16 MB max stream window
+		InitialConnectionReceiveWindow: 8 * 1024 * 1024,  // 8 MB initial connection window
+		MaxConnectionReceiveWindow:     32 * 1024 * 1024, // 32 MB max connection window
+	}
+
+	if profile == config.MemoryProfileLow {
+		// Small hosts: start small and let auto-tuning grow windows only when traffic needs it
+		quicConfig.InitialStreamReceiveWindow = 512 * 1024          // 512 KB
+		quicConfig.MaxStreamReceiveWindow = 4 * 1024 * 1024         // 4 MB
+		quicConfig.InitialConnectionReceiveWindow = 1 * 1024 * 1024 // 1 MB
+		quicConfig.MaxConnectionReceiveWindow = 8 * 1024 * 1024     // 8 MB
+	}
+
+	return quicConfig
+}
+
 // getRetryDelay calculates retry delay based on attempt count with exponential backoff
 func getRetryDelay(attempt int, authFailed bool, notLoggedIn bool) time.Duration {
 	// Special case: Not logged in - use longer delay to avoid spam
@@ -109,48 +175,78 @@ func getRetryDelay(attempt int, authFailed bool, notLoggedIn bool) time.Duration
 	}
 }
 
-func ConnectQuicServer() {
+// Connect runs the connection loop: discover a server, connect, authenticate and relay
+// Blocks forever, reconnecting with backoff while auto-reconnect is enabled
+func (c *Client) Connect() {
 	connectionAttempts := 0
 	consecutiveAuthFailures := 0
 	lastConnectionSuccessful := false
+	udpTimeouts := 0 // Consecutive QUIC dial timeouts while TCP to the server still works
+
+	if c.autoReconnectEnabled() {
+		logger.GetStatus().StartSession()
+	}
 
 	for {
 		// Check if auto-reconnect is disabled (user clicked "Stop Sharing")
-		autoReconnectMutex.RLock()
-		autoReconnect := shouldAutoReconnect
-		autoReconnectMutex.RUnlock()
+		// generation identifies this attempt; a Start/Stop in the meantime invalidates it
+		autoReconnect, stopReason, generation := c.connectState()
 
 		if !autoReconnect {
-			// User has disabled auto-reconnect, wait before checking again
-			logger.GetStatus().UpdateStatus("Stopped")
-			time.Sleep(5 * time.Second)
+			// User (or a terminal server close) has disabled auto-reconnect, wait before checking again
+			c.setState(StateStopped)
+			if stopReason != "" {
+				logger.GetStatus().UpdateStatus("Stopped: " + stopReason)
+			} else {
+				logger.GetStatus().UpdateStatus("Stopped")
+			}
+			c.sleep(5 * time.Second)
 			continue
 		}
 
+		c.beginAttempt()
 		ctx := context.Background()
 
 		// Determine server address using smart discovery
 		var serverAddr string
-		var apiURL string
+		apiURL := getAPIURL()
 
 		// DEBUG MODE: Use localhost servers for local development
-		if config.GlobalConfig.DebugMode {
+		if config.GetDebugMode() {
 			serverAddr = "127.0.0.1:8443"
-			apiURL = "http://127.0.0.1:8080"
 			log.Printf("DEBUG MODE: Using localhost server (QUIC: %s, API: %s)", serverAddr, apiURL)
+		} else if forced := getForcedServer(); forced != "" {
+			// SUPPORT OVERRIDE: -server flag skips discovery entirely
+			serverAddr = forced
+			log.Printf("FORCED SERVER: Skipping server discovery, using %s", serverAddr)
 		} else {
-			// PRODUCTION MODE: Use configured servers
-			apiURL = config.GlobalConfig.ServerURL
-			if apiURL == "" {
-				apiURL = "https://vyx.network"
-			} else if !strings.HasPrefix(apiURL, "http://") && !strings.HasPrefix(apiURL, "https://") {
-				// Add https:// if no protocol specified
-				apiURL = "https://" + apiURL
-			}
-
-			// Get optimal server address
+			// PRODUCTION MODE: Get optimal server address
 			// Try API discovery first, fallback to US server (closer to Asia)
 			serverAddr = GetOptimalServer(apiURL, "us.vyx.network:8443")
+			if serverAddr == "" {
+				// Every server is full (wait_when_busy): don't pile on, rediscover after a while
+				logger.GetStatus().UpdateStatus(allServersBusyStatus)
+				c.setState(StateReconnecting)
+				c.backoff(busyRetryDelay)
+				continue
+			}
+		}
+
+		// After a failure, check for a captive portal (public Wi-Fi) before dialing again
+		// Dials to a portal fail in confusing ways, so surface it instead of looping on backoff
+		if connectionAttempts > 0 && !config.GetDebugMode() && behindCaptivePortal() {
+			log.Println("Captive portal detected, waiting for network sign-in")
+			logger.GetStatus().UpdateStatus(captivePortalStatus)
+
+			retryDelay := getRetryDelay(connectionAttempts+1, false, false)
+			if retryDelay > 30*time.Second {
+				retryDelay = 30 * time.Second // Reconnect promptly once the user signs in
+			} else if retryDelay == 0 {
+				retryDelay = 5 * time.Second
+			}
+			c.backoff(retryDelay)
+			connectionAttempts++
+			continue
 		}
 
 		// Log connection attempt with attempt number
@@ -163,62 +259,140 @@ func ConnectQuicServer() {
 		// Build TLS config based on environment (dev vs production)
 		tlsConf := buildTLSConfig(serverAddr)
 
-		// Configure QUIC with longer timeouts for stable connections
-		// PERFORMANCE: Tuned for high-latency (200ms RTT) connections to server
-		quicConfig := &quic.Config{
-			MaxIdleTimeout:                 15 * time.Minute, // Keep connections alive for 15 minutes idle
-			KeepAlivePeriod:                30 * time.Second, // Send keepalive every 30 seconds
-			InitialStreamReceiveWindow:     4 * 1024 * 1024,  // 4 MB initial stream window (high BDP)
-			MaxStreamReceiveWindow:         16 * 1024 * 1024, // 16 MB max stream window
-			InitialConnectionReceiveWindow: 8 * 1024 * 1024,  // 8 MB initial connection window
-			MaxConnectionReceiveWindow:     32 * 1024 * 1024, // 32 MB max connection window
-		}
+		quicConfig := buildQUICConfig(config.GetMemoryProfile())
 
-		conn, err := quic.DialAddr(ctx, serverAddr, tlsConf, quicConfig)
-		if err != nil {
-			log.Printf("Failed to connect to QUIC server: %v", err)
-			logger.GetStatus().UpdateStatus(fmt.Sprintf("Connection failed (attempt %d)", connectionAttempts+1))
+		var conn serverConn
+		var stream serverStream
+		logger.GetStatus().TLSInfo = ""
+		if udpTimeouts >= udpBlockedThreshold && config.GetTCPFallbackEnabled() {
+			// UDP looks blocked on this network: carry the same message protocol over TLS/TCP
+			tcpConn, err := dialTCPFallback(ctx, serverAddr, tlsConf, config.GetTCPFallbackPort())
+			if err != nil {
+				logger.Warn("Failed to connect over TCP fallback: %v", err)
+				logger.GetStatus().UpdateStatus(fmt.Sprintf("Connection failed (attempt %d)", connectionAttempts+1))
+				logger.GetStatus().RecordFailure(fmt.Sprintf("TCP fallback failed: %v", err))
+				// The server may not offer TCP; go back to probing QUIC
+				udpTimeouts = 0
+				c.setState(StateReconnecting)
+
+				retryDelay := getRetryDelay(connectionAttempts+1, false, false)
+				log.Printf("Retrying in %v...", retryDelay)
+				c.backoff(retryDelay)
+				connectionAttempts++
+				continue
+			}
 
-			// Calculate retry delay
-			retryDelay := getRetryDelay(connectionAttempts+1, false, false)
-			log.Printf("Retrying in %v...", retryDelay)
+			log.Println("Connected to server over TCP fallback")
+			logger.GetStatus().UpdateStatus("Connected (TCP fallback)")
+			logger.GetStatus().ServerAddress = serverAddr
+			logger.GetStatus().TLSInfo = describeTLS(tcpConn.ConnectionState())
+			conn, stream = tcpConn, tcpConn
+		} else {
+			quicConn, err := quic.DialAddr(ctx, serverAddr, tlsConf, quicConfig)
+			if err != nil {
+				logger.Warn("Failed to connect to QUIC server: %v", err)
+				logger.GetStatus().UpdateStatus(fmt.Sprintf("Connection failed (attempt %d)", connectionAttempts+1))
+				if c.handleServerClose(err) {
+					connectionAttempts++
+					continue
+				}
+				if isQUICTimeout(err) && tcpReachable(serverAddr, config.GetTCPFallbackPort()) {
+					udpTimeouts++
+				} else {
+					udpTimeouts = 0
+				}
+				if udpTimeouts >= udpBlockedThreshold {
+					// Restrictive (corporate/campus) networks often drop all UDP; say so instead of retrying silently
+					log.Println("Server reachable over TCP but QUIC keeps timing out - UDP appears to be blocked by this network")
+					logger.GetStatus().UpdateStatus(udpBlockedStatus)
+					logger.GetStatus().RecordFailure(udpBlockedStatus)
+					if config.GetTCPFallbackEnabled() {
+						log.Println("TCP fallback enabled, next attempt will use TLS over TCP")
+					}
+				} else {
+					logger.GetStatus().RecordFailure(fmt.Sprintf("Connection failed: %v", err))
+				}
 
-			time.Sleep(retryDelay)
-			connectionAttempts++
-			continue
-		}
+				// Calculate retry delay
+				c.setState(StateReconnecting)
+				retryDelay := getRetryDelay(connectionAttempts+1, false, false)
+				log.Printf("Retrying in %v...", retryDelay)
 
-		log.Println("Connected to QUIC server")
-		logger.GetStatus().UpdateStatus("Connected")
-		logger.GetStatus().ServerAddress = serverAddr
+				c.backoff(retryDelay)
+				connectionAttempts++
+				continue
+			}
 
-		// let the server accept our bidirectional stream and register us
-		time.Sleep(100 * time.Millisecond)
+			udpTimeouts = 0
+			log.Println("Connected to QUIC server")
+			logger.GetStatus().UpdateStatus("Connected")
+			logger.GetStatus().ServerAddress = serverAddr
+			logger.GetStatus().TLSInfo = describeTLS(quicConn.ConnectionState().TLS)
 
-		stream, err := conn.OpenStreamSync(ctx)
-		if err != nil {
-			log.Printf("Failed to open QUIC stream: %v", err)
-			logger.GetStatus().UpdateStatus("Stream failed")
-			conn.CloseWithError(1, "failed to open stream")
+			// let the server accept our bidirectional stream and register us
+			c.sleep(100 * time.Millisecond)
 
-			retryDelay := getRetryDelay(connectionAttempts+1, false, false)
-			log.Printf("Retrying in %v...", retryDelay)
-			time.Sleep(retryDelay)
-			connectionAttempts++
-			continue
+			streamCtx, cancelStream := context.WithTimeout(ctx, openStreamTimeout)
+			quicStream, err := quicConn.OpenStreamSync(streamCtx)
+			cancelStream()
+			if err != nil {
+				logger.Warn("Failed to open QUIC stream: %v", err)
+				if isStreamCapacityRefusal(err) {
+					// Server is full: pick a different node right away instead of redialing this one
+					quicConn.CloseWithError(1, "stream refused")
+					penalizeServer(serverAddr, "refused a stream (at capacity)")
+					logger.GetStatus().UpdateStatus("Server full - switching servers")
+					logger.GetStatus().RecordFailure("Server refused stream (at capacity)")
+					c.sleep(capacityRetryDelay)
+					connectionAttempts++
+					continue
+				}
+				if c.handleServerClose(err) {
+					connectionAttempts++
+					continue
+				}
+				logger.GetStatus().UpdateStatus("Stream failed")
+				logger.GetStatus().RecordFailure(fmt.Sprintf("Failed to open stream: %v", err))
+				quicConn.CloseWithError(1, "failed to open stream")
+
+				retryDelay := getRetryDelay(connectionAttempts+1, false, false)
+				log.Printf("Retrying in %v...", retryDelay)
+				c.backoff(retryDelay)
+				connectionAttempts++
+				continue
+			}
+
+			conn, stream = quicConn, quicStream
 		}
 
-		quicMutex.Lock()
-		quicConn = conn
-		quicStream = stream
-		quicMutex.Unlock()
+		if !c.installConnection(conn, stream, generation) {
+			// User stopped or restarted sharing while we were dialing: don't leak this connection
+			log.Println("Sharing state changed while connecting, discarding connection")
+			conn.CloseWithError(0, "sharing state changed")
+			continue
+		}
+		c.setState(StateAuthenticating)
 
 		// Authenticate with server
-		authResult := authenticateWithServer(stream)
+		authErr := c.authenticateWithServer(stream)
 
-		if !authResult {
+		var maintenanceErr *maintenanceError
+		if errors.As(authErr, &maintenanceErr) {
+			// Planned downtime - not an auth problem, back off longer with jitter
+			conn.CloseWithError(0, "server maintenance")
+			c.waitForMaintenance(maintenanceErr)
+			continue
+		}
+
+		if authErr != nil && c.handleServerClose(authErr) {
+			connectionAttempts++
+			continue
+		}
+
+		if authErr != nil {
+			c.setState(StateReconnecting)
 			consecutiveAuthFailures++
-			log.Printf("Authentication failed (failure #%d)", consecutiveAuthFailures)
+			logger.Warn("Authentication failed (failure #%d)", consecutiveAuthFailures)
 
 			// Check if not logged in
 			notLoggedIn := !config.IsLoggedIn()
@@ -227,15 +401,32 @@ func ConnectQuicServer() {
 				log.Println("Not logged in. Waiting for user authentication...")
 			} else {
 				logger.GetStatus().UpdateStatus("Authentication failed")
-				log.Println("Authentication failed. Check credentials or API token.")
+				logger.Warn("Authentication failed. Check credentials or API token.")
 			}
+			logger.GetStatus().RecordFailure(authErr.Error())
 
 			conn.CloseWithError(1, "authentication failed")
 
-			// Use appropriate retry delay
+			var serverErr *serverAuthError
+			if errors.As(authErr, &serverErr) && !notLoggedIn && serverErr.isTokenRejected() {
+				// Token revoked or expired: drop it so the client shows as logged out, then prompt re-login
+				logger.Warn("Server rejected the stored token, re-login required")
+				if err := config.ClearAuthToken(); err != nil {
+					log.Printf("Warning: Failed to clear rejected token: %v", err)
+				}
+				logger.GetStatus().UpdateStatus("Session expired - please log in again")
+				notifyTokenRejected()
+				notLoggedIn = true
+			}
+
+			// Use appropriate retry delay (server-provided retry-after takes precedence)
 			retryDelay := getRetryDelay(connectionAttempts+1, true, notLoggedIn)
+			if serverErr != nil && serverErr.retryAfter > 0 {
+				log.Printf("Server requested retry after %v", serverErr.retryAfter)
+				retryDelay = serverErr.retryAfter
+			}
 			log.Printf("Retrying in %v...", retryDelay)
-			time.Sleep(retryDelay)
+			c.backoff(retryDelay)
 			connectionAttempts++
 			continue
 		}
@@ -244,18 +435,78 @@ func ConnectQuicServer() {
 		connectionAttempts = 0
 		consecutiveAuthFailures = 0
 		lastConnectionSuccessful = true
+		logger.GetStatus().ResetFailures()
 
 		log.Println("Successfully authenticated with server")
+		c.setState(StateConnected)
 		logger.GetStatus().UpdateStatus("Running")
+		// A forced server is a one-off diagnostic choice, don't let it become the sticky server
+		if !config.GetDebugMode() && getForcedServer() == "" {
+			if err := config.SetLastServer(serverAddr); err != nil {
+				logger.Warn("Failed to save last used server: %v", err)
+			}
+		}
 		logger.GetStatus().IsAuthenticated = true
 		logger.GetStatus().ConnectionUptime = time.Now()
 
+		// Extra relay streams (worker_streams) share this connection and end with it
+		c.openWorkerStreams(conn, generation)
+
 		// Run the reader (blocks until connection closes)
-		quicReader(stream)
+		authTime := time.Now()
+		readErr := c.quicReader(stream)
+		c.closeWorkers()
+		// A user Stop is not a lost connection - don't count it as a reconnect
+		if c.autoReconnectEnabled() {
+			logger.GetStatus().RecordReconnect(c.disconnectReason(readErr))
+		}
+
+		if errors.As(readErr, &maintenanceErr) {
+			logger.GetStatus().IsAuthenticated = false
+			logger.GetStatus().ConnectionUptime = time.Time{}
+			conn.CloseWithError(0, "server maintenance")
+			c.waitForMaintenance(maintenanceErr)
+			lastConnectionSuccessful = false
+			continue
+		}
+
+		var shutdownErr *serverShutdownError
+		if errors.As(readErr, &shutdownErr) {
+			// Planned rollout - reconnect immediately without backoff
+			logger.GetStatus().IsAuthenticated = false
+			logger.GetStatus().ConnectionUptime = time.Time{}
+			logger.GetStatus().UpdateStatus("Switching servers...")
+			conn.CloseWithError(0, "server shutdown")
+			if shutdownErr.nextServer != "" {
+				setPreferredServer(shutdownErr.nextServer)
+			}
+			lastConnectionSuccessful = false
+			continue
+		}
+
+		if errors.Is(readErr, errMessageTooLarge) {
+			// Don't keep talking to a server that sends oversized frames
+			conn.CloseWithError(closeCodeProtocolError, "message too large")
+		}
+
+		if c.handleServerClose(readErr) {
+			lastConnectionSuccessful = false
+			continue
+		}
 
 		// Connection closed - prepare to reconnect
+		if time.Since(authTime) < flakyConnectionThreshold && c.autoReconnectEnabled() {
+			// Accepted auth then dropped us immediately: steer selection to another server
+			penalizeServer(serverAddr, "dropped the connection right after auth")
+			lastConnectionSuccessful = false
+		}
 		log.Println("QUIC connection closed, reconnecting...")
 		logger.GetStatus().UpdateStatus("Reconnecting...")
+		if c.autoReconnectEnabled() {
+			c.setState(StateReconnecting)
+			// Only a failure if the user didn't stop sharing
+			logger.GetStatus().RecordFailure("Connection to server lost")
+		}
 		logger.GetStatus().IsAuthenticated = false
 		logger.GetStatus().ConnectionUptime = time.Time{}
 
@@ -263,20 +514,72 @@ func ConnectQuicServer() {
 		// Otherwise use progressive backoff
 		if lastConnectionSuccessful {
 			log.Println("Previous connection was successful, attempting quick reconnect...")
-			time.Sleep(2 * time.Second)
+			c.sleep(2 * time.Second)
 			lastConnectionSuccessful = false
 		} else {
 			retryDelay := getRetryDelay(1, false, false)
 			log.Printf("Reconnecting in %v...", retryDelay)
-			time.Sleep(retryDelay)
+			c.backoff(retryDelay)
 		}
 	}
 }
 
-func quicReader(stream *quic.Stream) {
-	decoder := json.NewDecoder(stream)
-	messageCount := 0
-	lastMessageTime := time.Now()
+// disconnectReason describes why an authenticated session was lost, for the reconnect history
+func (c *Client) disconnectReason(readErr error) string {
+	if readErr == nil {
+		return "connection closed"
+	}
+	return readErr.Error()
+}
+
+// getAPIURL returns the API base URL (localhost in debug mode, configured server otherwise)
+func getAPIURL() string {
+	if config.GetDebugMode() {
+		return "http://127.0.0.1:8080"
+	}
+
+	apiURL := ""
+	if cfg := config.Current(); cfg != nil {
+		apiURL = cfg.ServerURL
+	}
+	if apiURL == "" {
+		return "https://vyx.network"
+	}
+	if !strings.HasPrefix(apiURL, "http://") && !strings.HasPrefix(apiURL, "https://") {
+		// Add https:// if no protocol specified
+		apiURL = "https://" + apiURL
+	}
+	return apiURL
+}
+
+// waitForMaintenance shows a friendly maintenance status and waits for the jittered backoff
+// Start/Stop cuts the wait short, like any other reconnect backoff
+func (c *Client) waitForMaintenance(maintenanceErr *maintenanceError) {
+	retryDelay := maintenanceErr.retryDelay()
+	log.Printf("Server maintenance (%s), reconnecting in %v...", maintenanceErr.message, retryDelay.Round(time.Second))
+	logger.GetStatus().UpdateStatus("Server maintenance — reconnecting shortly")
+	c.backoff(retryDelay)
+}
+
+// quicReader processes server messages on the primary stream until the connection ends and returns the reason
+func (c *Client) quicReader(stream serverStream) error {
+	return c.readStream(stream, nil)
+}
+
+// readStream processes server messages on one stream until it ends and returns the reason
+// ws is the worker stream being read, or nil for the primary stream
+func (c *Client) readStream(stream serverStream, ws *workerStream) error {
+	limitReader := &messageLimitReader{r: stream}
+	decoder := json.NewDecoder(limitReader)
+	limitRate, limitBurst := config.GetConnectRateLimit()
+	limiter := newConnectLimiter(limitRate, limitBurst)
+
+	// Liveness (any message, including server pings) is tracked apart from proxy traffic,
+	// so an idle node that still receives keepalives isn't torn down
+	// All are shared with the health monitor goroutine, hence atomics
+	var lastMessageTime, lastTrafficTime, messageCount atomic.Int64
+	lastMessageTime.Store(time.Now().UnixNano())
+	lastTrafficTime.Store(time.Now().UnixNano())
 
 	// Start connection health monitor
 	healthTicker := time.NewTicker(30 * time.Second)
@@ -285,72 +588,93 @@ func quicReader(stream *quic.Stream) {
 	// Monitor channel for health checks
 	healthChan := make(chan bool, 1)
 
-	// Health monitor goroutine
-	go func() {
-		for range healthTicker.C {
-			timeSinceLastMessage := time.Since(lastMessageTime)
-
-			// If no messages for 3 minutes, log warning
-			if timeSinceLastMessage > 3*time.Minute {
-				log.Printf("Warning: No messages received for %v (connection may be stale)", timeSinceLastMessage)
-			}
+	// Stopping a ticker doesn't close its channel, so the monitor needs its own exit signal
+	readerDone := make(chan struct{})
+	defer close(readerDone)
+
+	// Health monitor goroutine (primary only: liveness pings are sent on the primary stream)
+	if ws == nil {
+		c.spawn(func() {
+			idleLogged := false
+			for {
+				select {
+				case <-readerDone:
+					return
+				case <-healthTicker.C:
+				}
+				timeSinceLastMessage := time.Since(time.Unix(0, lastMessageTime.Load())).Round(time.Second)
+				timeSinceLastTraffic := time.Since(time.Unix(0, lastTrafficTime.Load())).Round(time.Second)
+
+				// If no messages (not even keepalives) for 3 minutes, log warning
+				if timeSinceLastMessage > 3*time.Minute {
+					log.Printf("Warning: No messages received for %v (connection may be stale)", timeSinceLastMessage)
+				} else if timeSinceLastTraffic > 10*time.Minute && !idleLogged {
+					// Idle but healthy: keepalives still arrive, nothing to do
+					log.Printf("No proxy traffic for %v, connection alive (keepalives received)", timeSinceLastTraffic)
+					idleLogged = true
+				}
 
-			// If no messages for 10 minutes, consider connection dead
-			if timeSinceLastMessage > 10*time.Minute {
-				log.Printf("Connection appears dead (no messages for %v), triggering reconnect", timeSinceLastMessage)
-				healthChan <- false
-				return
+				// Only keepalives stopping means the connection is dead; idle traffic alone is fine
+				if timeSinceLastMessage > 10*time.Minute {
+					log.Printf("Connection appears dead (no messages for %v, %d received this session), triggering reconnect",
+						timeSinceLastMessage, messageCount.Load())
+					healthChan <- false
+					return
+				}
+				if timeSinceLastTraffic < 10*time.Minute {
+					idleLogged = false
+				}
 			}
-		}
-	}()
+		})
+	}
 
 	for {
 		select {
 		case <-healthChan:
 			// Health check failed, close connection
-			log.Println("Health check failed, closing connection")
-			clientMutex.Lock()
-			for id, cc := range clientConns {
-				cc.conn.Close()
-				close(cc.dataChan)
-				delete(clientConns, id)
-			}
-			clientMutex.Unlock()
-			return
+			logger.Warn("Health check failed, closing connection")
+			c.closeStreamConnections(ws)
+			return fmt.Errorf("health check failed: no messages received")
 
 		default:
 			// Set read deadline to avoid blocking forever
 			stream.SetReadDeadline(time.Now().Add(60 * time.Second))
 
 			var msg Message
+			limitReader.reset(maxMessageSize)
 			err := decoder.Decode(&msg)
 
 			if err != nil {
 				// Check if it's a timeout (expected during idle periods)
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 					// Timeout is normal during idle, just continue
+					// json.Decoder keeps returning its first error, so resume with a fresh decoder
+					// (including any partially read message); otherwise later pings are never seen
+					decoder = json.NewDecoder(io.MultiReader(decoder.Buffered(), limitReader))
 					continue
 				}
 
 				// Real error occurred
 				log.Printf("QUIC read error: %v", err)
-				logger.GetStatus().UpdateStatus("Connection lost")
+				if ws == nil {
+					logger.GetStatus().UpdateStatus("Connection lost")
+				}
 
 				// Clean up all client connections
-				clientMutex.Lock()
-				for id, cc := range clientConns {
-					cc.conn.Close()
-					close(cc.dataChan)
-					delete(clientConns, id)
-				}
-				clientMutex.Unlock()
+				c.closeStreamConnections(ws)
 
-				return
+				return fmt.Errorf("QUIC read error: %w", err)
 			}
 
 			// Update health tracking
-			messageCount++
-			lastMessageTime = time.Now()
+			messageCount.Add(1)
+			logger.GetStatus().CountMessage(msg.Type)
+			now := time.Now()
+			lastMessageTime.Store(now.UnixNano())
+			if isTrafficMessage(msg.Type) {
+				lastTrafficTime.Store(now.UnixNano())
+				logger.GetStatus().LastTraffic = now
+			}
 
 			// Privacy: Don't log message types or destination addresses
 			// log.Printf("received %+v", msg.Type)
@@ -359,10 +683,26 @@ func quicReader(stream *quic.Stream) {
 			case "connect":
 				// Privacy: Don't log destination addresses to protect proxy user privacy
 				// log.Println("to-to ", msg.Addr)
-				go handleConnect(msg)
+				if rate, burst := config.GetConnectRateLimit(); rate != limitRate || burst != limitBurst {
+					// Rate limit edited in config.json (hot reload)
+					limitRate, limitBurst = rate, burst
+					limiter = newConnectLimiter(rate, burst)
+				}
+				if !limiter.allow() {
+					// Over the connect rate limit: refuse instead of spawning another dial
+					log.Println("Connect rate limit exceeded, refusing connection")
+					c.spawn(func() { c.sendCloseMessageVia(ws, msg.ID) })
+					continue
+				}
+				c.spawn(func() { c.handleConnect(msg, ws) })
 			case "data":
-				clientMutex.RLock()
-				if cc, ok := clientConns[msg.ID]; ok {
+				if len(msg.Data) > maxEncodedDataSize {
+					logger.Warn("Protocol error: data message of %d bytes exceeds limit", len(msg.Data))
+					c.closeStreamConnections(ws)
+					return fmt.Errorf("data for connection %s: %w", msg.ID, errMessageTooLarge)
+				}
+				c.clientMutex.RLock()
+				if cc, ok := c.clientConns[msg.ID]; ok && !cc.writeDone.Load() {
 					if data, err := base64.StdEncoding.DecodeString(msg.Data); err == nil {
 						select {
 						case cc.dataChan <- data:
@@ -373,24 +713,48 @@ func quicReader(stream *quic.Stream) {
 						}
 					}
 				}
-				clientMutex.RUnlock()
+				c.clientMutex.RUnlock()
 			case "close":
-				clientMutex.Lock() // Write lock needed for delete
-				if cc, ok := clientConns[msg.ID]; ok {
-					cc.conn.Close()
-					close(cc.dataChan)
-					delete(clientConns, msg.ID)
+				c.clientMutex.Lock() // Write lock needed for delete
+				if cc, ok := c.clientConns[msg.ID]; ok {
+					if msg.Data == closeReasonReset {
+						// Peer side was reset: pass the reset on rather than finishing cleanly
+						cc.abortClose()
+					} else {
+						// Flush in-flight data to the destination instead of truncating it
+						cc.softClose()
+					}
+					c.forget(msg.ID, cc)
+					c.updateActiveConns()
+				}
+				c.clientMutex.Unlock()
+			case "half_close":
+				// Server is done sending for this connection; flush queued data then CloseWrite
+				c.clientMutex.RLock()
+				if cc, ok := c.clientConns[msg.ID]; ok && !cc.writeDone.Load() {
+					cc.writeDone.Store(true)
+					cc.closeData()
 				}
-				clientMutex.Unlock()
+				c.clientMutex.RUnlock()
 			case "ping":
-				err := sendMessage(&Message{
+				err := c.sendMessageVia(ws, &Message{
 					Type: "pong",
 					ID:   msg.ID,
 				})
 				if err != nil {
 					log.Printf("Error sending pong: %v", err)
-					return // Exit reader, will trigger reconnect
+					return fmt.Errorf("failed to send pong: %w", err) // Exit reader, will trigger reconnect
 				}
+			case "maintenance":
+				// Server is entering planned maintenance - disconnect and back off
+				log.Println("Server announced maintenance, disconnecting")
+				c.closeStreamConnections(ws)
+				return newMaintenanceError(msg.Data)
+			case "server_shutdown":
+				// Server is draining for a deploy - leave now and move to the recommended server
+				log.Println("Server is shutting down, switching servers")
+				c.closeStreamConnections(ws)
+				return &serverShutdownError{nextServer: msg.Addr, message: msg.Data}
 			default:
 				log.Printf("Warning: Unknown message type: %s", msg.Type)
 			}
@@ -398,23 +762,30 @@ func quicReader(stream *quic.Stream) {
 	}
 }
 
-func sendMessage(msg *Message) error {
-	quicMutex.Lock()
-	defer quicMutex.Unlock()
+// isTrafficMessage reports whether a message carries proxy work (as opposed to control/keepalive)
+func isTrafficMessage(msgType string) bool {
+	switch msgType {
+	case "connect", "data", "close", "half_close":
+		return true
+	}
+	return false
+}
+
+func (c *Client) sendMessage(msg *Message) error {
+	c.quicMutex.Lock()
+	defer c.quicMutex.Unlock()
 
-	if quicStream == nil {
+	if c.quicStream == nil {
 		log.Println("Cannot send message: no active QUIC stream")
 		return fmt.Errorf("no active QUIC stream")
 	}
 
-	data, err := json.Marshal(msg)
+	data, err := marshalMessage(msg)
 	if err != nil {
-		log.Printf("Failed to marshal message of type %s: %v", msg.Type, err)
 		return err
 	}
-	data = append(data, '\n')
 
-	_, err = quicStream.Write(data)
+	_, err = c.quicStream.Write(data)
 	if err != nil {
 		log.Printf("Error writing to QUIC stream: %v", err)
 		return err
@@ -423,95 +794,168 @@ func sendMessage(msg *Message) error {
 	return nil
 }
 
-func sendCloseMessage(id string) {
+// marshalMessage encodes msg as one newline-delimited JSON line
+func marshalMessage(msg *Message) ([]byte, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logger.Warn("Failed to marshal message of type %s: %v", msg.Type, err)
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// sendCloseMessage tells the server a connection is closed, on the stream it belongs to
+func (c *Client) sendCloseMessage(id string) {
+	c.sendCloseMessageVia(c.connWorker(id), id)
+}
+
+// sendCloseMessageVia closes a connection and tells the server on ws (nil = primary stream)
+func (c *Client) sendCloseMessageVia(ws *workerStream, id string) {
 	msg := Message{Type: "close", ID: id}
-	sendMessage(&msg)
-	clientMutex.Lock()
-	if cc, ok := clientConns[id]; ok {
+	c.sendMessageVia(ws, &msg)
+	c.removeConnection(id)
+}
+
+// removeConnection closes a relayed connection and forgets it
+// Shared teardown for every path that ends a connection, so the map entry and dataChan never leak
+func (c *Client) removeConnection(id string) {
+	c.clientMutex.Lock()
+	c.removeConnectionLocked(id)
+	c.updateActiveConns()
+	c.clientMutex.Unlock()
+}
+
+// removeConnectionLocked is removeConnection for callers holding clientMutex (count not updated)
+func (c *Client) removeConnectionLocked(id string) {
+	if cc, ok := c.clientConns[id]; ok {
 		cc.conn.Close()
-		close(cc.dataChan)
-		delete(clientConns, id)
+		cc.closeData()
+		c.forget(id, cc)
 	}
-	clientMutex.Unlock()
 }
 
-// DisconnectQuic closes the QUIC connection and disables auto-reconnect
+// forget drops a connection from clientConns, releases its host slot and records its lifetime; call with clientMutex held
+func (c *Client) forget(id string, cc *Connection) {
+	delete(c.clientConns, id)
+	c.releaseHostSlot(cc.hostSlot)
+	logger.GetStatus().RecordConnDuration(time.Since(cc.opened))
+}
+
+// closeAllConnections closes and forgets every relayed connection
+func (c *Client) closeAllConnections() {
+	c.clientMutex.Lock()
+	for id := range c.clientConns {
+		c.removeConnectionLocked(id)
+	}
+	c.updateActiveConns()
+	c.clientMutex.Unlock()
+}
+
+// Disconnect closes the QUIC connection and disables auto-reconnect
 // Used when user clicks "Stop Sharing" or logs out
-func DisconnectQuic() {
+func (c *Client) Disconnect() {
+	c.stateMutex.Lock()
+
 	// Disable auto-reconnect first
-	autoReconnectMutex.Lock()
-	shouldAutoReconnect = false
-	autoReconnectMutex.Unlock()
+	c.autoReconnectMutex.Lock()
+	c.shouldAutoReconnect = false
+	c.generation++
+	c.autoReconnectMutex.Unlock()
+	stopped := c.newGeneration()
+	c.setState(StateStopped)
+
+	// Relays exiting from here on must not message the server we're tearing down
+	c.relaysStopping.Store(true)
+
+	c.quicMutex.Lock()
+	c.closeWorkersLocked()
+	if c.quicConn != nil {
+		c.quicConn.CloseWithError(0, "user stopped sharing")
+		c.quicConn = nil
+	}
+
+	if c.quicStream != nil {
+		c.quicStream.Close()
+		c.quicStream = nil
+	}
+	c.quicMutex.Unlock()
+
+	c.stateMutex.Unlock()
+	c.wakeConnectLoop()
 
-	quicMutex.Lock()
-	defer quicMutex.Unlock()
+	// Close all client connections (this unblocks the relays)
+	c.closeAllConnections()
 
-	if quicConn != nil {
-		quicConn.CloseWithError(0, "user stopped sharing")
-		quicConn = nil
+	if c.waitTasks(stopped, shutdownTimeout) {
+		log.Println("All connection goroutines stopped")
 	}
 
-	if quicStream != nil {
-		quicStream.Close()
-		quicStream = nil
+	if summary := logger.GetStatus().EndSession(); summary != "" {
+		log.Println(summary)
 	}
+}
 
-	// Close all client connections
-	clientMutex.Lock()
-	for id, cc := range clientConns {
-		cc.conn.Close()
-		close(cc.dataChan)
-		delete(clientConns, id)
+// authenticateWithServer sends authentication credentials to server on the primary stream
+func (c *Client) authenticateWithServer(stream serverStream) error {
+	var extra map[string]string
+	if count := config.GetWorkerStreams(); count > 1 {
+		// Let the server know worker streams will follow on this connection
+		extra = map[string]string{"worker_streams": strconv.Itoa(count)}
 	}
-	clientMutex.Unlock()
+	return c.authenticateStream(stream, extra)
 }
 
-// authenticateWithServer sends authentication credentials to server
-func authenticateWithServer(stream *quic.Stream) bool {
+// authenticateStream authenticates one stream, adding extra to the client metadata
+func (c *Client) authenticateStream(stream serverStream, extra map[string]string) error {
 	// Reload config if it's nil
-	if config.GlobalConfig == nil {
+	if config.Current() == nil {
 		log.Println("Config is nil, reloading...")
 		cfg, err := config.LoadConfig()
 		if err != nil {
-			log.Printf("Failed to reload config: %v", err)
-			return false
+			logger.Warn("Failed to reload config: %v", err)
+			return fmt.Errorf("failed to load config: %w", err)
 		}
-		log.Printf("Config reloaded - IsLoggedIn: %v, Email: %s", config.IsLoggedIn(), cfg.Email)
+		log.Printf("Config reloaded - IsLoggedIn: %v, Email: %s", config.IsLoggedIn(), config.RedactPersonal(cfg.Email))
 	}
 
 	// Check if user is logged in
 	if !config.IsLoggedIn() {
 		log.Println("ERROR: Not logged in. Please login via the system tray menu.")
 		log.Println("Click 'Connect' in the system tray to authenticate.")
-		return false
+		return fmt.Errorf("not logged in")
 	}
 
 	// Create client metadata
 	metadata := map[string]string{
-		"client_type":    "desktop",
-		"os":             getOSName(),
-		"os_version":     getOSVersion(),
-		"client_version": "1.0.0",
+		"client_type":      "desktop",
+		"os":               getOSName(),
+		"os_version":       getOSVersion(),
+		"client_version":   version.Version,
+		"protocol_version": strconv.Itoa(ProtocolVersion),
+	}
+	for key, value := range extra {
+		metadata[key] = value
 	}
 
 	metadataJSON, err := json.Marshal(metadata)
 	if err != nil {
-		log.Printf("Failed to marshal metadata: %v", err)
+		logger.Warn("Failed to marshal metadata: %v", err)
 		metadataJSON = []byte("{}")
 	}
 
 	// Send authentication message
+	token := config.Current().APIToken
 	authMsg := Message{
 		Type: "auth",
-		ID:   config.GlobalConfig.APIToken,
+		ID:   token,
 		Data: string(metadataJSON),
 	}
 
-	log.Printf("Sending auth message with token: %s...", config.GlobalConfig.APIToken[:min(10, len(config.GlobalConfig.APIToken))])
+	log.Printf("Sending auth message with token %s", config.RedactToken(token))
 	encoder := json.NewEncoder(stream)
 	if err := encoder.Encode(authMsg); err != nil {
-		log.Printf("Failed to send authentication: %v", err)
-		return false
+		logger.Warn("Failed to send authentication: %v", err)
+		return fmt.Errorf("failed to send authentication: %w", err)
 	}
 	log.Println("Auth message sent, waiting for response...")
 
@@ -519,7 +963,7 @@ func authenticateWithServer(stream *quic.Stream) bool {
 	responseChan := make(chan Message, 1)
 	errorChan := make(chan error, 1)
 
-	go func() {
+	c.spawn(func() {
 		decoder := json.NewDecoder(stream)
 		var response Message
 		if err := decoder.Decode(&response); err != nil {
@@ -527,27 +971,40 @@ func authenticateWithServer(stream *quic.Stream) bool {
 			return
 		}
 		responseChan <- response
-	}()
+	})
 
 	select {
 	case response := <-responseChan:
 		log.Printf("Received response type: %s", response.Type)
 		if response.Type == "auth_success" {
-			log.Printf("Authenticated as: %s", response.Data)
-			return true
+			identity, negotiated := parseAuthSuccess(response.Data)
+			if negotiated > ProtocolVersion {
+				return fmt.Errorf("server selected unsupported protocol version %d (client supports up to %d)", negotiated, ProtocolVersion)
+			}
+			c.protocolVersion.Store(int32(negotiated))
+			log.Printf("Authenticated as: %s (protocol v%d)", config.RedactPersonal(identity), negotiated)
+			return nil
+		}
+		if response.Type == "maintenance" {
+			log.Printf("Server is in maintenance: %s", response.Data)
+			return newMaintenanceError(response.Data)
 		}
 		if response.Type == "error" {
-			log.Printf("Authentication error: %s", response.Data)
-			return false
+			logger.Warn("Authentication error: %s", response.Data)
+			serverErr := newServerAuthError(response.Data)
+			if serverErr.isMaintenance() {
+				return &maintenanceError{message: serverErr.message, retryAfter: serverErr.retryAfter}
+			}
+			return serverErr
 		}
 		log.Printf("Unexpected response type: %s, Data: %s", response.Type, response.Data)
-		return false
+		return fmt.Errorf("unexpected auth response type: %s", response.Type)
 	case err := <-errorChan:
-		log.Printf("Failed to read auth response: %v", err)
-		return false
+		logger.Warn("Failed to read auth response: %v", err)
+		return fmt.Errorf("failed to read auth response: %w", err)
 	case <-time.After(10 * time.Second):
 		log.Println("Authentication timeout")
-		return false
+		return fmt.Errorf("authentication timeout")
 	}
 }
 
@@ -565,17 +1022,7 @@ func getOSName() string {
 	}
 }
 
-// getOSVersion returns the OS version string
+// getOSVersion returns the detailed OS version string (e.g. "Windows 11 Pro 23H2 (10.0.22631)")
 func getOSVersion() string {
-	switch runtime.GOOS {
-	case "windows":
-		// Could query Windows version via WMI, but simplified for now
-		return "Windows"
-	case "darwin":
-		return "macOS"
-	case "linux":
-		return "Linux"
-	default:
-		return runtime.GOOS
-	}
+	return platform.OSVersion()
 }
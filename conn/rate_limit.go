@@ -0,0 +1,56 @@
+package conn
+
+import (
+	"sync"
+	"time"
+)
+
+// connectLimiter is a token bucket limiting how fast new connections are established
+// A nil limiter allows everything
+type connectLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // Tokens added per second
+	burst    float64 // Bucket capacity
+	tokens   float64
+	lastFill time.Time
+}
+
+// newConnectLimiter creates a limiter allowing rate connects/second with the given burst
+// Returns nil (unlimited) when rate is not positive
+func newConnectLimiter(rate float64, burst int) *connectLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &connectLimiter{
+		rate:     rate,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+	}
+}
+
+// allow consumes a token if one is available
+func (l *connectLimiter) allow() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.lastFill).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.lastFill = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
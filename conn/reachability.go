@@ -0,0 +1,44 @@
+package conn
+
+import (
+	"net"
+	"time"
+)
+
+// ReachabilityReport describes one test dial from this node to a destination
+type ReachabilityReport struct {
+	Target      string
+	PortAllowed bool          // Whether allowed_ports would let the node relay to this destination
+	DNSPath     string        // How the host was resolved (cache, system DNS, fallback resolver, or none for an IP)
+	RemoteAddr  string        // Address that accepted the connection (empty on failure)
+	Family      string        // "IPv4" or "IPv6" for RemoteAddr
+	Duration    time.Duration // Time to resolve and connect
+	Err         error         // Why the dial failed (nil on success)
+}
+
+// TestReachability dials target (host:port) the same way relayed connections are dialed and reports the path taken
+// Meant for the test-dial developer tool: nothing is logged, so destination privacy is unaffected
+func TestReachability(target string) ReachabilityReport {
+	report := ReachabilityReport{
+		Target:      target,
+		PortAllowed: targetPortAllowed(target),
+	}
+
+	trace := &dialTrace{}
+	start := time.Now()
+	conn, err := dialWithTrace(target, trace)
+	report.Duration = time.Since(start)
+	report.DNSPath = trace.dnsPath
+	if err != nil {
+		report.Err = err
+		return report
+	}
+	defer conn.Close()
+
+	report.RemoteAddr = conn.RemoteAddr().String()
+	report.Family = "IPv4"
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok && tcpAddr.IP.To4() == nil {
+		report.Family = "IPv6"
+	}
+	return report
+}
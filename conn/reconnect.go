@@ -1,36 +1,114 @@
 package conn
 
-import "log"
+import (
+	"client/logger"
+	"log"
+	"time"
+)
 
-// ReconnectQuic forces a reconnection to the QUIC server and enables auto-reconnect
+// Reconnect forces a reconnection to the QUIC server and enables auto-reconnect
 // Used when user clicks "Start Sharing" or logs in
-func ReconnectQuic() {
+func (c *Client) Reconnect() {
 	log.Println("Enabling bandwidth sharing...")
 
+	c.stateMutex.Lock()
+
 	// Enable auto-reconnect first
-	autoReconnectMutex.Lock()
-	shouldAutoReconnect = true
-	autoReconnectMutex.Unlock()
+	c.autoReconnectMutex.Lock()
+	c.shouldAutoReconnect = true
+	c.stopReason = ""
+	c.generation++
+	c.autoReconnectMutex.Unlock()
+	c.newGeneration() // The old connection's goroutines drain on their own
+	c.relaysStopping.Store(false)
+	if c.CurrentState() == StateStopped {
+		c.setState(StateConnecting)
+	}
 
 	// Close existing connection if any
-	quicMutex.Lock()
-	if quicConn != nil {
-		quicConn.CloseWithError(0, "reconnecting")
-		quicConn = nil
+	c.quicMutex.Lock()
+	c.closeWorkersLocked()
+	if c.quicConn != nil {
+		c.quicConn.CloseWithError(0, "reconnecting")
+		c.quicConn = nil
 	}
-	if quicStream != nil {
-		quicStream.Close()
-		quicStream = nil
+	if c.quicStream != nil {
+		c.quicStream.Close()
+		c.quicStream = nil
 	}
-	quicMutex.Unlock()
+	c.quicMutex.Unlock()
+
+	c.stateMutex.Unlock()
+	c.wakeConnectLoop()
+	logger.GetStatus().StartSession()
 
-	// The ConnectQuicServer goroutine will automatically retry now that auto-reconnect is enabled
+	// The Connect loop will automatically retry now that auto-reconnect is enabled
 	log.Println("Auto-reconnect enabled, will connect shortly...")
 }
 
 // IsConnected returns true if currently connected to QUIC server
-func IsConnected() bool {
-	quicMutex.Lock()
-	defer quicMutex.Unlock()
-	return quicConn != nil && quicStream != nil
+func (c *Client) IsConnected() bool {
+	c.quicMutex.Lock()
+	defer c.quicMutex.Unlock()
+	return c.quicConn != nil && c.quicStream != nil
+}
+
+// autoReconnectEnabled reports whether the user wants the client to stay connected
+func (c *Client) autoReconnectEnabled() bool {
+	c.autoReconnectMutex.RLock()
+	defer c.autoReconnectMutex.RUnlock()
+	return c.shouldAutoReconnect
+}
+
+// connectState returns whether sharing is enabled, why it was stopped, and the current generation
+func (c *Client) connectState() (enabled bool, stopReason string, generation uint64) {
+	c.autoReconnectMutex.RLock()
+	defer c.autoReconnectMutex.RUnlock()
+	return c.shouldAutoReconnect, c.stopReason, c.generation
+}
+
+// installConnection makes conn the active connection unless a Start/Stop happened since generation
+// Returns false if the caller must discard conn (the user's intent changed while it was dialing)
+func (c *Client) installConnection(conn serverConn, stream serverStream, generation uint64) bool {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	enabled, _, current := c.connectState()
+	if !enabled || current != generation {
+		return false
+	}
+
+	c.quicMutex.Lock()
+	c.quicConn = conn
+	c.quicStream = stream
+	c.quicMutex.Unlock()
+	return true
+}
+
+// wakeConnectLoop cuts short any backoff sleep so the Connect loop acts on the new state now
+func (c *Client) wakeConnectLoop() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// backoff is sleep for a retry delay, published as NextReconnectAt so the tray can count down
+func (c *Client) backoff(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	logger.GetStatus().NextReconnectAt = time.Now().Add(d)
+	c.sleep(d)
+	logger.GetStatus().NextReconnectAt = time.Time{}
+}
+
+// sleep waits for d, returning early if Start/Stop was clicked
+func (c *Client) sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.wake:
+	}
 }
@@ -1,17 +1,42 @@
 package conn
 
 import (
+	"client/logger"
 	"encoding/base64"
+	"errors"
+	"io"
 	"log"
+	"net"
 )
 
-func relayFromConnToQuic(cc *Connection, id string) {
+// closeReasonReset is sent as the Data of a "close" when the connection ended on an error (e.g. a reset)
+// rather than a clean EOF, so the server can end the peer's side abruptly too; older servers ignore it
+const closeReasonReset = "reset"
+
+// closeWriter is implemented by connections that support half-close (e.g. *net.TCPConn)
+type closeWriter interface {
+	CloseWrite() error
+}
+
+func (c *Client) relayFromConnToQuic(cc *Connection, id string) {
+	halfClosed := false
+	reset := false
 	defer func() {
 		// Ensure cleanup on exit
 		if r := recover(); r != nil {
-			log.Printf("Panic in relayFromConnToQuic for connection %s: %v", id, r)
+			logger.Error("Panic in relayFromConnToQuic for connection %s: %v", id, r)
+			halfClosed = false
+		}
+		if c.relaysStopping.Load() {
+			// Disconnecting: the server connection is already gone
+			cc.conn.Close()
+			return
+		}
+		if reset {
+			c.sendResetMessage(id)
+		} else if !halfClosed {
+			c.sendCloseMessage(id)
 		}
-		sendCloseMessage(id)
 	}()
 
 	// PERFORMANCE: Larger buffers for high-latency links (200ms RTT to server)
@@ -20,7 +45,15 @@ func relayFromConnToQuic(cc *Connection, id string) {
 	for {
 		n, err := cc.conn.Read(buf)
 		if err != nil {
-			// Connection closed or error, exit gracefully
+			if errors.Is(err, io.EOF) {
+				if !cc.writeDone.Load() {
+					// Destination finished writing but may still read: half-close this direction only
+					halfClosed = c.sendHalfClose(cc, id)
+				}
+			} else if !errors.Is(err, net.ErrClosed) {
+				// Reset or failure on the destination side, not a clean end (ErrClosed is our own close)
+				reset = true
+			}
 			return
 		}
 
@@ -32,22 +65,39 @@ func relayFromConnToQuic(cc *Connection, id string) {
 		data := base64.StdEncoding.EncodeToString(buf[:n])
 		msg := Message{Type: "data", ID: id, Data: data}
 
-		err = sendMessage(&msg)
+		err = c.sendMessageVia(cc.worker, &msg)
 		if err != nil {
 			// Failed to send, connection to server likely lost
 			log.Printf("Failed to relay data from client connection %s: %v", id, err)
 			return
 		}
+		logger.GetStatus().AddDataSent(n)
 	}
 }
 
-func relayFromChanToConn(cc *Connection, id string) {
+func (c *Client) relayFromChanToConn(cc *Connection, id string) {
+	reset := false
 	defer func() {
 		// Ensure cleanup on exit
 		if r := recover(); r != nil {
-			log.Printf("Panic in relayFromChanToConn for connection %s: %v", id, r)
+			logger.Error("Panic in relayFromChanToConn for connection %s: %v", id, r)
+		}
+		if c.relaysStopping.Load() {
+			// Disconnecting: the server connection is already gone
+			cc.conn.Close()
+			return
+		}
+		if cc.writeDone.Load() && !cc.readDone.Load() && cc.closeWrite() {
+			// Server half-closed: keep reading from the destination
+			return
+		}
+		// After a soft close the connection is no longer tracked, so close it here
+		cc.conn.Close()
+		if reset {
+			c.sendResetMessage(id)
+		} else {
+			c.sendCloseMessage(id)
 		}
-		sendCloseMessage(id)
 	}()
 
 	for data := range cc.dataChan {
@@ -55,10 +105,50 @@ func relayFromChanToConn(cc *Connection, id string) {
 			continue
 		}
 
-		_, err := cc.conn.Write(data)
+		n, err := cc.conn.Write(data)
+		logger.GetStatus().AddDataRecv(n)
 		if err != nil {
-			// Connection closed or error, exit gracefully
+			// Data was lost (reset, or a soft close that couldn't flush in time) unless we closed it ourselves
+			reset = !errors.Is(err, net.ErrClosed)
 			return
 		}
 	}
 }
+
+// sendResetMessage closes a connection and tells the server it ended on an error, not a clean close
+func (c *Client) sendResetMessage(id string) {
+	msg := Message{Type: "close", ID: id, Data: closeReasonReset}
+	c.sendMessageVia(c.connWorker(id), &msg)
+	c.removeConnection(id)
+}
+
+// abortClose closes the destination with a TCP reset instead of a FIN, discarding queued data
+// Used when the server reports the proxy user's side was reset, so the destination sees the same
+func (cc *Connection) abortClose() {
+	if tcpConn, ok := cc.conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+	cc.conn.Close()
+	cc.closeData()
+}
+
+// sendHalfClose tells the server the destination has finished writing
+// Returns false if the message could not be sent and the connection should be fully closed
+func (c *Client) sendHalfClose(cc *Connection, id string) bool {
+	cc.readDone.Store(true)
+	if err := c.sendMessageVia(cc.worker, &Message{Type: "half_close", ID: id}); err != nil {
+		log.Printf("Failed to send half-close for connection %s: %v", id, err)
+		return false
+	}
+	return true
+}
+
+// closeWrite half-closes the destination after the server has finished sending
+// Returns false if half-close is unsupported and the connection should be fully closed
+func (cc *Connection) closeWrite() bool {
+	cw, ok := cc.conn.(closeWriter)
+	if !ok {
+		return false
+	}
+	return cw.CloseWrite() == nil
+}
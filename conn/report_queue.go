@@ -0,0 +1,149 @@
+package conn
+
+import (
+	"bufio"
+	"bytes"
+	"client/config"
+	"client/logger"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Offline report queue limits: old or excess reports are dropped rather than growing without bound
+const (
+	reportQueueMaxBytes = 256 * 1024
+	reportQueueMaxAge   = 7 * 24 * time.Hour
+	reportQueueInterval = 5 * time.Minute
+)
+
+// queuedReport is one API report that couldn't be delivered, stored as a JSON line
+type queuedReport struct {
+	Kind    string          `json:"kind"` // Selects the endpoint, e.g. "telemetry"
+	Queued  time.Time       `json:"queued"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// reportQueueMutex serializes appends and drains of the queue file
+var reportQueueMutex sync.Mutex
+
+// reportQueuePath is the append-only queue file in ~/.vyx
+func reportQueuePath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".vyx", "report_queue.jsonl")
+}
+
+// queueReport stores a report that failed to send so the drainer can retry it later
+// Reports are dropped once the file reaches reportQueueMaxBytes
+func queueReport(kind string, payload []byte) {
+	line, err := json.Marshal(queuedReport{Kind: kind, Queued: time.Now(), Payload: payload})
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	reportQueueMutex.Lock()
+	defer reportQueueMutex.Unlock()
+
+	path := reportQueuePath()
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > reportQueueMaxBytes {
+		log.Printf("Report queue full, dropping %s report", kind)
+		return
+	}
+
+	os.MkdirAll(filepath.Dir(path), 0755)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		logger.Warn("Failed to queue %s report: %v", kind, err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(line); err != nil {
+		logger.Warn("Failed to queue %s report: %v", kind, err)
+	}
+}
+
+// StartReportQueueDrainer periodically resends queued reports once the API is reachable again
+func StartReportQueueDrainer() {
+	go func() {
+		ticker := time.NewTicker(reportQueueInterval)
+		defer ticker.Stop()
+		for {
+			drainReportQueue()
+			<-ticker.C
+		}
+	}()
+}
+
+// drainReportQueue sends queued reports oldest first, stopping at the first failure
+// Expired reports, and telemetry once the user has opted out, are discarded instead of sent
+func drainReportQueue() {
+	reportQueueMutex.Lock()
+	defer reportQueueMutex.Unlock()
+
+	path := reportQueuePath()
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return
+	}
+
+	var remaining bytes.Buffer
+	sent, dropped := 0, 0
+	failed := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), reportQueueMaxBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var report queuedReport
+		if err := json.Unmarshal(line, &report); err != nil {
+			dropped++ // Corrupt line (e.g. partial write) - skip it
+			continue
+		}
+		if time.Since(report.Queued) > reportQueueMaxAge || !reportStillWanted(report.Kind) {
+			dropped++
+			continue
+		}
+		if !failed {
+			if err := postReport(report.Kind, report.Payload); err == nil {
+				sent++
+				continue
+			}
+			failed = true // Still offline - keep this and everything after it for next time
+		}
+		remaining.Write(line)
+		remaining.WriteByte('\n')
+	}
+
+	if sent == 0 && dropped == 0 {
+		return
+	}
+	if remaining.Len() == 0 {
+		os.Remove(path)
+	} else if err := os.WriteFile(path, remaining.Bytes(), 0600); err != nil {
+		logger.Warn("Failed to update report queue: %v", err)
+	}
+	log.Printf("Report queue: sent %d, discarded %d", sent, dropped)
+}
+
+// reportStillWanted reports whether a queued report of kind may still be sent
+func reportStillWanted(kind string) bool {
+	switch kind {
+	case "telemetry":
+		// PRIVACY: opting out also cancels reports queued before
+		return config.GetTelemetryEnabled()
+	}
+	return false
+}
+
+// postReport sends a queued payload to the endpoint for kind
+func postReport(kind string, payload []byte) error {
+	switch kind {
+	case "telemetry":
+		return postTelemetry(payload)
+	}
+	return nil
+}
@@ -2,14 +2,27 @@ package conn
 
 import (
 	"client/config"
+	"client/logger"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
+// allServersBusyStatus is shown while waiting for capacity (server_selection.wait_when_busy)
+const allServersBusyStatus = "All servers busy — waiting"
+
+// busyRetryDelay is how long to wait before rediscovering when every server is overloaded
+const busyRetryDelay = 60 * time.Second
+
+// errAllServersBusy means every server is above the overload cutoff and wait_when_busy is set
+var errAllServersBusy = errors.New("all servers are above the overload cutoff")
+
 // ServerInfo represents a QUIC server from the API
 type ServerInfo struct {
 	ID          string `json:"id"`
@@ -37,11 +50,9 @@ type ServerListResponse struct {
 // DiscoverServers fetches the list of available servers from the API
 func DiscoverServers(apiURL string) ([]ServerInfo, error) {
 	// Fetch server list with timeout
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
+	client := config.NewHTTPClient(config.HTTPTimeoutQuick)
 
-	resp, err := client.Get(apiURL + "/api/servers")
+	resp, err := client.Get(apiURL + config.GetAPIPaths().Servers)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch server list: %w", err)
 	}
@@ -60,20 +71,151 @@ func DiscoverServers(apiURL string) ([]ServerInfo, error) {
 		return nil, fmt.Errorf("no servers available")
 	}
 
+	// Canonicalize addresses so IPv6 literals are bracketed before anything splits on ':'
+	for i := range response.Servers {
+		response.Servers[i].Address = normalizeServerAddr(response.Servers[i].Address)
+	}
+
 	log.Printf("Discovered %d servers from API", len(response.Servers))
 	return response.Servers, nil
 }
 
+// defaultServerPort is the QUIC port assumed when a discovered address has none
+const defaultServerPort = "8443"
+
+// normalizeServerAddr returns addr as host:port with IPv6 literals bracketed
+// Accepts "host:port", "[v6]:port", bare hosts and bare IPv4/IPv6 literals (which get the default port)
+func normalizeServerAddr(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		return net.JoinHostPort(host, port)
+	}
+	if ip := net.ParseIP(strings.Trim(addr, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), defaultServerPort)
+	}
+	if !strings.Contains(addr, ":") {
+		return net.JoinHostPort(addr, defaultServerPort)
+	}
+	log.Printf("Warning: Could not parse server address %q", addr)
+	return addr
+}
+
+// serverHost extracts the host from a server address, without brackets for IPv6
+func serverHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	// No port: address is a bare host or IP literal
+	return strings.Trim(addr, "[]")
+}
+
+// LatencyProbe measures round-trip latency to a server address
+type LatencyProbe func(address string) time.Duration
+
+var (
+	latencyProbe      LatencyProbe = TestLatency // Default: TCP connect probe to port 443
+	latencyProbeMutex sync.RWMutex
+)
+
+// SetLatencyProbe replaces the latency probe used by SelectBestServer
+// Passing nil restores the default TCP probe (TestLatency)
+func SetLatencyProbe(probe LatencyProbe) {
+	if probe == nil {
+		probe = TestLatency
+	}
+	latencyProbeMutex.Lock()
+	latencyProbe = probe
+	latencyProbeMutex.Unlock()
+}
+
+var (
+	preferredServer      string // One-shot server override set by a server_shutdown notice
+	preferredServerMutex sync.Mutex
+)
+
+// setPreferredServer makes the next GetOptimalServer call return addr instead of discovering
+func setPreferredServer(addr string) {
+	preferredServerMutex.Lock()
+	preferredServer = addr
+	preferredServerMutex.Unlock()
+}
+
+// takePreferredServer returns and clears the one-shot preferred server
+func takePreferredServer() string {
+	preferredServerMutex.Lock()
+	defer preferredServerMutex.Unlock()
+	addr := preferredServer
+	preferredServer = ""
+	return addr
+}
+
+var (
+	forcedServer      string // Server pinned by the -server flag, bypasses discovery for the whole run
+	forcedServerMutex sync.RWMutex
+)
+
+// ForceServer pins every connection attempt to addr, bypassing discovery and server selection
+// Meant for support diagnostics; the override is not saved to config
+func ForceServer(addr string) {
+	forcedServerMutex.Lock()
+	forcedServer = normalizeServerAddr(addr)
+	forcedServerMutex.Unlock()
+}
+
+// getForcedServer returns the server pinned by ForceServer, or "" if none
+func getForcedServer() string {
+	forcedServerMutex.RLock()
+	defer forcedServerMutex.RUnlock()
+	return forcedServer
+}
+
+// Server penalty: servers that drop us right after auth or refuse streams are avoided for a while
+const (
+	flakyConnectionThreshold = 5 * time.Second // Connections shorter than this count as flaky
+	serverPenaltyCooldown    = 10 * time.Minute
+)
+
+var (
+	penalizedServers      = make(map[string]time.Time) // Address -> penalty expiry
+	penalizedServersMutex sync.Mutex
+)
+
+// penalizeServer excludes addr from selection until the cooldown expires
+func penalizeServer(addr string, reason string) {
+	penalizedServersMutex.Lock()
+	penalizedServers[addr] = time.Now().Add(serverPenaltyCooldown)
+	penalizedServersMutex.Unlock()
+	log.Printf("Server %s %s, avoiding it for %v", addr, reason, serverPenaltyCooldown)
+}
+
+// isServerPenalized reports whether addr is still in its penalty cooldown
+func isServerPenalized(addr string) bool {
+	penalizedServersMutex.Lock()
+	defer penalizedServersMutex.Unlock()
+	expiry, ok := penalizedServers[addr]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiry) {
+		delete(penalizedServers, addr)
+		return false
+	}
+	return true
+}
+
+// getLatencyProbe returns the currently configured latency probe
+func getLatencyProbe() LatencyProbe {
+	latencyProbeMutex.RLock()
+	defer latencyProbeMutex.RUnlock()
+	return latencyProbe
+}
+
 // TestLatency measures latency to a server address (TCP connection probe)
 func TestLatency(address string) time.Duration {
 	start := time.Now()
 
-	// Extract host from address (e.g., "us.vyx.network:8443" → "us.vyx.network")
-	host, _, err := net.SplitHostPort(address)
-	if err != nil {
-		// If no port, use address as-is
-		host = address
-	}
+	// Extract host from address (e.g., "us.vyx.network:8443" → "us.vyx.network", "[2001:db8::1]:8443" → "2001:db8::1")
+	host := serverHost(address)
 
 	// Test latency to HTTPS port (443) instead of QUIC port (8443)
 	// QUIC port is UDP-only, but we need TCP for latency measurement
@@ -90,6 +232,32 @@ func TestLatency(address string) time.Duration {
 	return latency
 }
 
+// stickyMaxUtilization is the load above which we stop preferring the last used server
+const stickyMaxUtilization = 80.0
+
+// stickyServer returns the last successfully used server if it's among candidates and not degraded
+func stickyServer(candidates []ServerInfo) (ServerInfo, bool) {
+	last := config.GetLastServer()
+	if last == "" {
+		return ServerInfo{}, false
+	}
+
+	for _, s := range candidates {
+		if s.Address != last {
+			continue
+		}
+		if s.Status != "healthy" || isServerPenalized(s.Address) {
+			return ServerInfo{}, false
+		}
+		if s.Connections.UtilizationPercent > stickyMaxUtilization {
+			log.Printf("Last used server %s is busy (%.1f%% utilization), rebalancing", s.Name, s.Connections.UtilizationPercent)
+			return ServerInfo{}, false
+		}
+		return s, true
+	}
+	return ServerInfo{}, false
+}
+
 // SelectBestServer chooses the optimal server based on load and latency
 func SelectBestServer(servers []ServerInfo) (string, error) {
 	if len(servers) == 0 {
@@ -109,7 +277,35 @@ func SelectBestServer(servers []ServerInfo) (string, error) {
 		healthy = servers
 	}
 
-	// If only one server, use it
+	// Skip servers that recently dropped us right after auth (unless that leaves nothing)
+	available := make([]ServerInfo, 0, len(healthy))
+	for _, s := range healthy {
+		if isServerPenalized(s.Address) {
+			log.Printf("Skipping recently flaky server: %s", s.Name)
+			continue
+		}
+		available = append(available, s)
+	}
+	if len(available) > 0 {
+		healthy = available
+	} else {
+		log.Println("Warning: All servers recently flaky, ignoring penalties")
+	}
+
+	// Stay on the last good server while it's healthy, so reconnects keep session context
+	if sticky, ok := stickyServer(healthy); ok {
+		log.Printf("Selected server: %s (%s) - last used server still healthy (%.1f%% utilization)",
+			sticky.Name, sticky.Address, sticky.Connections.UtilizationPercent)
+		return sticky.Address, nil
+	}
+
+	weights := config.GetServerSelection()
+
+	// If only one server, use it (unless it's full and we'd rather wait)
+	if len(healthy) == 1 && weights.WaitWhenBusy && healthy[0].Connections.UtilizationPercent > weights.OverloadPercent {
+		log.Printf("Only available server %s is overloaded (%.1f%% utilization), waiting", healthy[0].Name, healthy[0].Connections.UtilizationPercent)
+		return "", errAllServersBusy
+	}
 	if len(healthy) == 1 {
 		log.Printf("Selected server: %s (%s) - only available server", healthy[0].Name, healthy[0].Address)
 		return healthy[0].Address, nil
@@ -123,22 +319,23 @@ func SelectBestServer(servers []ServerInfo) (string, error) {
 	}
 
 	scores := make([]serverScore, 0, len(healthy))
+	probe := getLatencyProbe()
 
 	for _, server := range healthy {
-		// Skip overloaded servers (>90% utilization)
-		if server.Connections.UtilizationPercent > 90 {
+		// Skip overloaded servers (>90% utilization by default, server_selection.overload_percent)
+		if server.Connections.UtilizationPercent > weights.OverloadPercent {
 			log.Printf("Skipping overloaded server: %s (%.1f%% utilization)", server.Name, server.Connections.UtilizationPercent)
 			continue
 		}
 
-		latency := TestLatency(server.Address)
+		latency := probe(server.Address)
 
 		// Calculate score: weighted combination of load and latency
-		// Load weight: 60%, Latency weight: 40%
+		// Default load weight: 60%, latency weight: 40% (server_selection in config)
 		loadScore := server.Connections.UtilizationPercent
 		latencyScore := float64(latency.Milliseconds()) / 10.0 // Normalize to 0-100 range
 
-		totalScore := (loadScore * 0.6) + (latencyScore * 0.4)
+		totalScore := (loadScore * weights.LoadWeight) + (latencyScore * weights.LatencyWeight)
 
 		scores = append(scores, serverScore{
 			server:  server,
@@ -151,6 +348,10 @@ func SelectBestServer(servers []ServerInfo) (string, error) {
 	}
 
 	if len(scores) == 0 {
+		if weights.WaitWhenBusy {
+			log.Println("All servers overloaded, waiting instead of connecting to a full server")
+			return "", errAllServersBusy
+		}
 		// All servers overloaded, use least loaded
 		best := healthy[0]
 		for _, s := range healthy[1:] {
@@ -177,25 +378,35 @@ func SelectBestServer(servers []ServerInfo) (string, error) {
 }
 
 // GetOptimalServer discovers and selects the best server, with DNS fallback
+// Returns "" when every server is busy and server_selection.wait_when_busy is set
 func GetOptimalServer(apiURL string, fallbackAddr string) string {
 	// DEBUG MODE: Skip server discovery and use localhost
-	if config.GlobalConfig != nil && config.GlobalConfig.DebugMode {
+	if config.GetDebugMode() {
 		debugAddr := "127.0.0.1:8443"
 		log.Printf("DEBUG MODE: Skipping server discovery, using localhost: %s", debugAddr)
 		return debugAddr
 	}
 
+	// A draining server told us where to go next - use it once, skipping discovery
+	if addr := takePreferredServer(); addr != "" {
+		log.Printf("Using server recommended by previous server: %s", addr)
+		return addr
+	}
+
 	// Try API-based discovery first
 	servers, err := DiscoverServers(apiURL)
 	if err != nil {
-		log.Printf("Server discovery failed: %v, using fallback: %s", err, fallbackAddr)
+		logger.Warn("Server discovery failed: %v, using fallback: %s", err, fallbackAddr)
 		return fallbackAddr
 	}
 
 	// Select best server
 	bestAddr, err := SelectBestServer(servers)
+	if errors.Is(err, errAllServersBusy) {
+		return ""
+	}
 	if err != nil {
-		log.Printf("Failed to select server: %v, using fallback: %s", err, fallbackAddr)
+		logger.Warn("Failed to select server: %v, using fallback: %s", err, fallbackAddr)
 		return fallbackAddr
 	}
 
@@ -0,0 +1,183 @@
+package conn
+
+import (
+	"client/config"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testServer builds a ServerInfo at name:8443 with the given status and utilization
+func testServer(name, status string, utilization float64) ServerInfo {
+	s := ServerInfo{Name: name, Address: name + ":8443", Status: status}
+	s.Connections.UtilizationPercent = utilization
+	return s
+}
+
+func TestSelectBestServer(t *testing.T) {
+	tests := []struct {
+		name      string
+		servers   []ServerInfo
+		latencies map[string]time.Duration // By server name; unlisted servers answer in 50ms
+		config    config.Config
+		want      string
+		wantErr   error
+	}{
+		{
+			name:    "no servers",
+			wantErr: errors.New("no servers available"),
+		},
+		{
+			name: "healthy preferred over unhealthy",
+			servers: []ServerInfo{
+				testServer("down", "unhealthy", 0),
+				testServer("up", "healthy", 70),
+			},
+			latencies: map[string]time.Duration{"down": time.Millisecond},
+			want:      "up:8443",
+		},
+		{
+			name: "all unhealthy falls back to all servers",
+			servers: []ServerInfo{
+				testServer("a", "unhealthy", 60),
+				testServer("b", "degraded", 10),
+			},
+			want: "b:8443",
+		},
+		{
+			name: "overloaded server skipped",
+			servers: []ServerInfo{
+				testServer("full", "healthy", 95),
+				testServer("spare", "healthy", 50),
+			},
+			latencies: map[string]time.Duration{"full": time.Millisecond, "spare": 500 * time.Millisecond},
+			want:      "spare:8443",
+		},
+		{
+			name: "all overloaded uses least loaded",
+			servers: []ServerInfo{
+				testServer("a", "healthy", 99),
+				testServer("b", "healthy", 92),
+				testServer("c", "healthy", 97),
+			},
+			want: "b:8443",
+		},
+		{
+			name: "equal load picks lowest latency",
+			servers: []ServerInfo{
+				testServer("far", "healthy", 40),
+				testServer("near", "healthy", 40),
+				testServer("mid", "healthy", 40),
+			},
+			latencies: map[string]time.Duration{
+				"far":  400 * time.Millisecond,
+				"near": 20 * time.Millisecond,
+				"mid":  150 * time.Millisecond,
+			},
+			want: "near:8443",
+		},
+		{
+			name: "default weights trade load against latency",
+			servers: []ServerInfo{
+				testServer("idle-far", "healthy", 10),
+				testServer("busy-near", "healthy", 40),
+			},
+			latencies: map[string]time.Duration{"idle-far": 900 * time.Millisecond, "busy-near": 10 * time.Millisecond},
+			want:      "busy-near:8443", // 10*0.6+90*0.4=42 vs 40*0.6+1*0.4=24.4
+		},
+		{
+			name: "load-only weights ignore latency",
+			servers: []ServerInfo{
+				testServer("idle-far", "healthy", 10),
+				testServer("busy-near", "healthy", 40),
+			},
+			latencies: map[string]time.Duration{"idle-far": 900 * time.Millisecond, "busy-near": 10 * time.Millisecond},
+			config:    config.Config{ServerSelection: &config.ServerSelection{LoadWeight: 1}},
+			want:      "idle-far:8443",
+		},
+		{
+			name: "custom overload cutoff",
+			servers: []ServerInfo{
+				testServer("a", "healthy", 60),
+				testServer("b", "healthy", 40),
+			},
+			latencies: map[string]time.Duration{"a": 10 * time.Millisecond, "b": 500 * time.Millisecond},
+			config:    config.Config{ServerSelection: &config.ServerSelection{OverloadPercent: 50}},
+			want:      "b:8443", // a would win on score (36.4 vs 44) but is above the cutoff
+		},
+		{
+			name: "wait_when_busy with all servers overloaded",
+			servers: []ServerInfo{
+				testServer("a", "healthy", 99),
+				testServer("b", "healthy", 92),
+			},
+			config:  config.Config{ServerSelection: &config.ServerSelection{WaitWhenBusy: true}},
+			wantErr: errAllServersBusy,
+		},
+		{
+			name:    "wait_when_busy with the only server overloaded",
+			servers: []ServerInfo{testServer("only", "healthy", 95)},
+			config:  config.Config{ServerSelection: &config.ServerSelection{WaitWhenBusy: true}},
+			wantErr: errAllServersBusy,
+		},
+		{
+			name:    "only server used even when overloaded",
+			servers: []ServerInfo{testServer("only", "healthy", 95)},
+			want:    "only:8443",
+		},
+		{
+			name: "wait_when_busy still connects when one server has room",
+			servers: []ServerInfo{
+				testServer("a", "healthy", 99),
+				testServer("b", "healthy", 30),
+			},
+			config: config.Config{ServerSelection: &config.ServerSelection{WaitWhenBusy: true}},
+			want:   "b:8443",
+		},
+		{
+			name: "last server kept while healthy",
+			servers: []ServerInfo{
+				testServer("last", "healthy", 70),
+				testServer("better", "healthy", 5),
+			},
+			latencies: map[string]time.Duration{"last": 300 * time.Millisecond, "better": 5 * time.Millisecond},
+			config:    config.Config{LastServer: "last:8443"},
+			want:      "last:8443",
+		},
+	}
+
+	savedConfig := config.Current()
+	t.Cleanup(func() {
+		config.SetCurrent(savedConfig)
+		SetLatencyProbe(nil)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.config
+			config.SetCurrent(&cfg)
+
+			SetLatencyProbe(func(address string) time.Duration {
+				host := serverHost(address)
+				if latency, ok := tt.latencies[host]; ok {
+					return latency
+				}
+				return 50 * time.Millisecond
+			})
+
+			got, err := SelectBestServer(tt.servers)
+			if tt.wantErr != nil {
+				if err == nil || (!errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error()) {
+					t.Fatalf("SelectBestServer() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectBestServer() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SelectBestServer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
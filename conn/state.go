@@ -0,0 +1,104 @@
+package conn
+
+import "log"
+
+// State is the lifecycle state of a client's server connection
+type State int32
+
+const (
+	StateDisconnected   State = iota // Initial state, connect loop not started yet
+	StateConnecting                  // Discovering a server and dialing
+	StateAuthenticating              // Connected, waiting for auth_success
+	StateConnected                   // Authenticated and relaying traffic
+	StateReconnecting                // Connection lost or failed, backing off before the next attempt
+	StateStopped                     // Sharing disabled by the user or a terminal server close
+)
+
+// String returns the display name of the state
+func (s State) String() string {
+	switch s {
+	case StateDisconnected:
+		return "Disconnected"
+	case StateConnecting:
+		return "Connecting"
+	case StateAuthenticating:
+		return "Authenticating"
+	case StateConnected:
+		return "Connected"
+	case StateReconnecting:
+		return "Reconnecting"
+	case StateStopped:
+		return "Stopped"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsSharing reports whether the client is trying to share (anything but Disconnected or Stopped)
+func (s State) IsSharing() bool {
+	return s != StateDisconnected && s != StateStopped
+}
+
+// stateTransitions lists the allowed next states; Stopped is reachable from everywhere
+var stateTransitions = map[State][]State{
+	StateDisconnected:   {StateConnecting},
+	StateConnecting:     {StateAuthenticating, StateReconnecting},
+	StateAuthenticating: {StateConnected, StateReconnecting},
+	StateConnected:      {StateReconnecting},
+	StateReconnecting:   {StateConnecting},
+	StateStopped:        {StateConnecting},
+}
+
+// canTransition reports whether from -> to is a valid lifecycle step
+func canTransition(from, to State) bool {
+	if to == StateStopped {
+		return true
+	}
+	for _, next := range stateTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentState returns the client's connection state
+func (c *Client) CurrentState() State {
+	return State(c.state.Load())
+}
+
+// setState moves to the given state if the transition is allowed
+// Invalid transitions are logged and ignored so a bug can't put the UI in a nonsense state
+func (c *Client) setState(to State) bool {
+	for {
+		from := c.CurrentState()
+		if from == to {
+			return true
+		}
+		if from == StateStopped && to == StateReconnecting {
+			// An attempt in flight when the user clicked Stop failed afterwards - stay stopped
+			return false
+		}
+		if !canTransition(from, to) {
+			log.Printf("Warning: Ignoring invalid connection state change %s -> %s", from, to)
+			return false
+		}
+		if c.state.CompareAndSwap(int32(from), int32(to)) {
+			return true
+		}
+	}
+}
+
+// beginAttempt enters Connecting, passing through Reconnecting if the previous attempt didn't finish cleanly
+func (c *Client) beginAttempt() {
+	switch c.CurrentState() {
+	case StateConnecting, StateAuthenticating, StateConnected:
+		c.setState(StateReconnecting)
+	}
+	c.setState(StateConnecting)
+}
+
+// CurrentState returns the default client's connection state
+func CurrentState() State {
+	return defaultClient.CurrentState()
+}
@@ -0,0 +1,64 @@
+package conn
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// serverConn is the connection to the server (QUIC, or TLS over TCP when UDP is blocked)
+type serverConn interface {
+	CloseWithError(code quic.ApplicationErrorCode, msg string) error
+}
+
+// serverStream is the bidirectional message stream carrying the JSON protocol
+type serverStream interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+}
+
+// tcpServerConn runs the message protocol over a single TLS/TCP stream
+// It serves as both the connection and its only stream
+type tcpServerConn struct {
+	*tls.Conn
+}
+
+// CloseWithError closes the TCP connection (TCP has no application close codes)
+func (t *tcpServerConn) CloseWithError(code quic.ApplicationErrorCode, msg string) error {
+	return t.Conn.Close()
+}
+
+// dialTCPFallback connects to the server over TLS/TCP using the same ALPN and certificate checks as QUIC
+func dialTCPFallback(ctx context.Context, serverAddr string, tlsConf *tls.Config, port int) (*tcpServerConn, error) {
+	addr, err := tcpFallbackAddr(serverAddr, port)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second},
+		Config:    tlsConf,
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpServerConn{Conn: conn.(*tls.Conn)}, nil
+}
+
+// tcpFallbackAddr returns the address the TCP fallback dials: the QUIC port, or port when set (tcp_fallback_port)
+func tcpFallbackAddr(serverAddr string, port int) (string, error) {
+	host, quicPort, err := net.SplitHostPort(serverAddr)
+	if err != nil {
+		return "", err
+	}
+	if port > 0 {
+		return net.JoinHostPort(host, strconv.Itoa(port)), nil
+	}
+	return net.JoinHostPort(host, quicPort), nil
+}
@@ -0,0 +1,106 @@
+package conn
+
+import (
+	"bytes"
+	"client/config"
+	"client/logger"
+	"client/version"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Telemetry reporting schedule (first report waits so short-lived runs send nothing)
+const (
+	telemetryInitialDelay = 10 * time.Minute
+	telemetryInterval     = 1 * time.Hour
+)
+
+// telemetryReport is the anonymous payload sent when telemetry is enabled
+// PRIVACY: Only aggregate counters - no destinations, user IDs, emails, tokens or server addresses
+type telemetryReport struct {
+	OS               string `json:"os"`
+	OSVersion        string `json:"os_version"`
+	ClientVersion    string `json:"client_version"`
+	UptimeSeconds    int64  `json:"uptime_seconds"`
+	ConnectedSeconds int64  `json:"connected_seconds"`
+	Reconnects       int    `json:"reconnects"`
+	Failures         int    `json:"consecutive_failures"`
+	ActiveConns      int    `json:"active_connections"`
+	BytesSent        uint64 `json:"bytes_sent"`
+	BytesReceived    uint64 `json:"bytes_received"`
+}
+
+// StartTelemetryReporter periodically posts anonymous stats while telemetry is opted in
+// The preference is checked on every tick so toggling it takes effect without a restart
+func StartTelemetryReporter() {
+	startTime := time.Now()
+
+	go func() {
+		time.Sleep(telemetryInitialDelay)
+
+		ticker := time.NewTicker(telemetryInterval)
+		defer ticker.Stop()
+
+		for {
+			if config.GetTelemetryEnabled() {
+				if err := sendTelemetry(buildTelemetryReport(startTime)); err != nil {
+					logger.Warn("Telemetry report failed: %v", err)
+				}
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// buildTelemetryReport collects aggregate stats from the status logger
+func buildTelemetryReport(startTime time.Time) *telemetryReport {
+	status := logger.GetStatus()
+
+	report := &telemetryReport{
+		OS:            getOSName(),
+		OSVersion:     getOSVersion(),
+		ClientVersion: version.Version,
+		UptimeSeconds: int64(time.Since(startTime).Seconds()),
+		Reconnects:    status.SessionReconnects,
+		Failures:      status.ConsecutiveFailures,
+		ActiveConns:   status.ActiveConns,
+		BytesSent:     status.TotalDataSent,
+		BytesReceived: status.TotalDataRecv,
+	}
+	if !status.ConnectionUptime.IsZero() {
+		report.ConnectedSeconds = int64(time.Since(status.ConnectionUptime).Seconds())
+	}
+	return report
+}
+
+// sendTelemetry posts a report to the API's telemetry endpoint
+// Reports that can't be delivered are queued on disk and retried by the report queue drainer
+func sendTelemetry(report *telemetryReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	if err := postTelemetry(body); err != nil {
+		queueReport("telemetry", body)
+		return err
+	}
+	return nil
+}
+
+// postTelemetry sends an encoded telemetry report
+func postTelemetry(body []byte) error {
+	client := config.NewHTTPClient(config.HTTPTimeoutDefault)
+
+	resp, err := client.Post(getAPIURL()+config.GetAPIPaths().Telemetry, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package conn
+
+import (
+	"client/config"
+	"client/logger"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// tokenValidationTick is how often the validator wakes to see whether a check is due
+// The interval itself is read from config on every tick so edits apply without a restart
+const tokenValidationTick = 1 * time.Minute
+
+// tokenRevokedReason is shown in the status when the API reports the stored token as revoked
+const tokenRevokedReason = "Session expired - please log in again"
+
+var (
+	// errTokenRevoked means the API no longer accepts the stored token
+	errTokenRevoked = fmt.Errorf("token revoked")
+	// errValidateUnsupported means the API has no validation endpoint (e.g. an older self-hosted backend)
+	errValidateUnsupported = fmt.Errorf("token validation endpoint not found")
+)
+
+// StartTokenValidator periodically re-checks the stored token while sharing
+// Disabled unless token_validation_minutes is set; a revoked token stops sharing and prompts re-login
+func StartTokenValidator() {
+	go defaultClient.runTokenValidator()
+}
+
+// runTokenValidator checks the token whenever the configured interval has passed while connected
+func (c *Client) runTokenValidator() {
+	var lastCheck time.Time
+	unsupportedLogged := false
+
+	ticker := time.NewTicker(tokenValidationTick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		interval := config.GetTokenValidationInterval()
+		if interval <= 0 || config.GetDebugMode() {
+			continue
+		}
+		// Only worth checking while relaying; a fresh (re)auth validates the token anyway
+		if c.CurrentState() != StateConnected || !config.IsLoggedIn() {
+			lastCheck = time.Time{}
+			continue
+		}
+		if lastCheck.IsZero() {
+			lastCheck = time.Now() // Just authenticated - start counting from here
+			continue
+		}
+		if time.Since(lastCheck) < interval {
+			continue
+		}
+		lastCheck = time.Now()
+
+		err := validateToken(config.Current().APIToken)
+		switch {
+		case err == nil:
+			unsupportedLogged = false
+		case err == errTokenRevoked:
+			c.handleTokenRevoked()
+			lastCheck = time.Time{}
+		case err == errValidateUnsupported:
+			if !unsupportedLogged {
+				log.Println("Token validation is not supported by this API, skipping checks")
+				unsupportedLogged = true
+			}
+		default:
+			// Network or server trouble is not a verdict on the token - keep sharing and retry next interval
+			logger.Warn("Token validation failed: %v", err)
+		}
+	}
+}
+
+// validateToken asks the API whether token is still valid
+// Returns errTokenRevoked on 401/403 or an explicit {"valid": false}
+func validateToken(token string) error {
+	req, err := http.NewRequest(http.MethodGet, getAPIURL()+config.GetAPIPaths().Validate, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := config.NewHTTPClient(config.HTTPTimeoutDefault)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return errTokenRevoked
+	case resp.StatusCode == http.StatusNotFound:
+		return errValidateUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Valid *bool `json:"valid"`
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return err
+	}
+	if json.Unmarshal(body, &result) == nil && result.Valid != nil && !*result.Valid {
+		return errTokenRevoked
+	}
+	return nil
+}
+
+// handleTokenRevoked stops sharing and prompts re-login, like a token rejection during auth
+func (c *Client) handleTokenRevoked() {
+	log.Println("API reports the stored token as revoked, stopping sharing until re-login")
+
+	// Set the reason first so the stopped connect loop shows it instead of a plain "Stopped"
+	c.autoReconnectMutex.Lock()
+	c.stopReason = tokenRevokedReason
+	c.autoReconnectMutex.Unlock()
+	c.Disconnect()
+
+	if err := config.ClearAuthToken(); err != nil {
+		log.Printf("Warning: Failed to clear revoked token: %v", err)
+	}
+	logger.GetStatus().RecordFailure("API token revoked")
+	logger.GetStatus().UpdateStatus(tokenRevokedReason)
+	notifyTokenRejected()
+}
@@ -0,0 +1,37 @@
+package conn
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+const (
+	// udpBlockedThreshold is how many consecutive QUIC timeouts (with TCP working) mean UDP is blocked
+	udpBlockedThreshold = 2
+	udpBlockedStatus    = "UDP blocked — QUIC cannot connect"
+)
+
+// isQUICTimeout reports whether a dial failed because the server never answered over UDP
+func isQUICTimeout(err error) bool {
+	var handshakeErr *quic.HandshakeTimeoutError
+	var idleErr *quic.IdleTimeoutError
+	return errors.As(err, &handshakeErr) || errors.As(err, &idleErr)
+}
+
+// tcpReachable checks whether the server accepts TCP on the port the TCP fallback would use (see tcpFallbackAddr)
+// If TCP works while QUIC times out, the network is dropping UDP rather than the server being down
+func tcpReachable(serverAddr string, fallbackPort int) bool {
+	addr, err := tcpFallbackAddr(serverAddr, fallbackPort)
+	if err != nil {
+		return false
+	}
+	conn, err := net.DialTimeout("tcp", addr, 3*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -1,6 +1,7 @@
 package conn
 
 import (
+	"client/config"
 	"io"
 	"log"
 	"net"
@@ -24,8 +25,8 @@ func UIDCollector() string {
 
 		uid := string(bodyBytes)
 
-		log.Printf("Received UID: %+v\n", uid)
-		sendMessage(&Message{Type: "uid-register", ID: uid})
+		log.Printf("Received UID: %s\n", config.RedactPersonal(uid))
+		defaultClient.sendMessage(&Message{Type: "uid-register", ID: uid})
 
 		w.WriteHeader(http.StatusOK)
 
@@ -0,0 +1,173 @@
+package conn
+
+import (
+	"client/config"
+	"client/logger"
+	"context"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+)
+
+// workerStream is an extra authenticated stream on the server connection (worker_streams > 1)
+// The server picks the stream for each connect; the connection is bound to it and all replies go back on it,
+// so relay writes are spread over several stream locks instead of one
+type workerStream struct {
+	stream serverStream
+	index  int
+	mu     sync.Mutex // Serializes writes to stream
+}
+
+// streamOpener is implemented by QUIC connections; the TCP fallback has only its single stream
+type streamOpener interface {
+	OpenStreamSync(ctx context.Context) (*quic.Stream, error)
+}
+
+// workerMetadata marks an auth message as opening worker stream index for an existing session
+func workerMetadata(index int) map[string]string {
+	return map[string]string{
+		"stream_role":  "worker",
+		"stream_index": strconv.Itoa(index),
+	}
+}
+
+// openWorkerStreams opens and authenticates worker_streams-1 extra streams and starts their readers
+// Any failure (e.g. a server without worker stream support) just leaves fewer streams in use
+func (c *Client) openWorkerStreams(conn serverConn, generation uint64) {
+	count := config.GetWorkerStreams()
+	if count <= 1 {
+		return
+	}
+	opener, ok := conn.(streamOpener)
+	if !ok {
+		log.Println("worker_streams ignored: the TCP fallback carries a single stream")
+		return
+	}
+
+	opened := 1 // The primary stream
+	for i := 1; i < count; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), openStreamTimeout)
+		stream, err := opener.OpenStreamSync(ctx)
+		cancel()
+		if err != nil {
+			logger.Warn("Failed to open worker stream %d: %v", i, err)
+			break
+		}
+		if err := c.authenticateStream(stream, workerMetadata(i)); err != nil {
+			log.Printf("Server did not accept worker stream %d: %v", i, err)
+			stream.Close()
+			break
+		}
+
+		ws := &workerStream{stream: stream, index: i}
+		if !c.addWorker(ws, generation) {
+			// Sharing was stopped or restarted meanwhile
+			stream.Close()
+			return
+		}
+		c.spawn(func() { c.runWorker(ws) })
+		opened++
+	}
+
+	log.Printf("Relaying over %d of %d requested streams", opened, count)
+}
+
+// addWorker registers ws unless a Start/Stop happened since generation
+func (c *Client) addWorker(ws *workerStream, generation uint64) bool {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	enabled, _, current := c.connectState()
+	if !enabled || current != generation {
+		return false
+	}
+
+	c.quicMutex.Lock()
+	c.workers = append(c.workers, ws)
+	c.quicMutex.Unlock()
+	return true
+}
+
+// runWorker reads a worker stream until it ends, then drops it and its connections
+// The primary stream and the other workers keep running
+func (c *Client) runWorker(ws *workerStream) {
+	err := c.readStream(ws.stream, ws)
+	log.Printf("Worker stream %d closed: %v", ws.index, err)
+
+	c.quicMutex.Lock()
+	for i, w := range c.workers {
+		if w == ws {
+			c.workers = append(c.workers[:i], c.workers[i+1:]...)
+			break
+		}
+	}
+	c.quicMutex.Unlock()
+
+	ws.stream.Close()
+	c.closeStreamConnections(ws)
+}
+
+// closeWorkersLocked closes every worker stream; call with quicMutex held
+func (c *Client) closeWorkersLocked() {
+	for _, ws := range c.workers {
+		ws.stream.Close()
+	}
+	c.workers = nil
+}
+
+// closeWorkers closes every worker stream (their readers exit and clean up)
+func (c *Client) closeWorkers() {
+	c.quicMutex.Lock()
+	c.closeWorkersLocked()
+	c.quicMutex.Unlock()
+}
+
+// connWorker returns the stream connection id is bound to (nil = primary stream or unknown)
+func (c *Client) connWorker(id string) *workerStream {
+	c.clientMutex.RLock()
+	defer c.clientMutex.RUnlock()
+	if cc, ok := c.clientConns[id]; ok {
+		return cc.worker
+	}
+	return nil
+}
+
+// sendMessageVia sends msg on ws, or on the primary stream when ws is nil
+func (c *Client) sendMessageVia(ws *workerStream, msg *Message) error {
+	if ws == nil {
+		return c.sendMessage(msg)
+	}
+
+	data, err := marshalMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if _, err := ws.stream.Write(data); err != nil {
+		log.Printf("Error writing to worker stream %d: %v", ws.index, err)
+		return err
+	}
+	return nil
+}
+
+// closeStreamConnections closes the connections bound to ws, or all connections for the primary stream
+// Losing the primary stream means the whole server connection is going away
+func (c *Client) closeStreamConnections(ws *workerStream) {
+	if ws == nil {
+		c.closeAllConnections()
+		return
+	}
+
+	c.clientMutex.Lock()
+	for id, cc := range c.clientConns {
+		if cc.worker == ws {
+			c.removeConnectionLocked(id)
+		}
+	}
+	c.updateActiveConns()
+	c.clientMutex.Unlock()
+}
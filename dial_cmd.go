@@ -0,0 +1,40 @@
+package main
+
+import (
+	"client/config"
+	"client/conn"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// runTestDialCommand handles `vyx test-dial <host:port>`: checks whether this node can reach a destination
+// Prints to the terminal only; destinations are never written to the log
+func runTestDialCommand(target string) int {
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		fmt.Fprintln(os.Stderr, "Usage: vyx-client test-dial <host:port>")
+		return 2
+	}
+	// Fallback DNS and allowed_ports come from config
+	if _, err := config.LoadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not load config, using defaults: %v\n", err)
+	}
+
+	report := conn.TestReachability(target)
+
+	fmt.Printf("Destination:   %s\n", report.Target)
+	if !report.PortAllowed {
+		fmt.Println("Allowed ports: NO - allowed_ports in config would refuse this destination")
+	}
+	if report.DNSPath != "" {
+		fmt.Printf("DNS:           %s\n", report.DNSPath)
+	}
+	if report.Err != nil {
+		fmt.Printf("Result:        FAILED after %v: %v\n", report.Duration.Round(time.Millisecond), report.Err)
+		return 1
+	}
+	fmt.Printf("Connected to:  %s (%s)\n", report.RemoteAddr, report.Family)
+	fmt.Printf("Result:        OK in %v\n", report.Duration.Round(time.Millisecond))
+	return 0
+}
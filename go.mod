@@ -4,10 +4,12 @@ go 1.24.0
 
 require (
 	github.com/getlantern/systray v1.2.2
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/quic-go/quic-go v0.55.0
 	github.com/zalando/go-keyring v0.2.6
 	golang.org/x/mod v0.29.0
 	golang.org/x/sys v0.37.0
+	golang.org/x/term v0.36.0
 )
 
 require (
@@ -22,7 +24,6 @@ require (
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
-	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/oxtoacart/bpool v0.0.0-20190530202638-03653db5a59c // indirect
 	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
 	go.opentelemetry.io/otel v1.38.0 // indirect
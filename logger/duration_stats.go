@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// durationBuckets are the upper bounds of the connection-duration histogram; longer connections land in the last bucket
+// Mostly sub-second connections often mean destinations are failing or refusing through this node's egress
+var durationBuckets = [...]struct {
+	max   time.Duration
+	label string
+}{
+	{time.Second, "<1s"},
+	{10 * time.Second, "1-10s"},
+	{time.Minute, "10s-1m"},
+	{10 * time.Minute, "1-10m"},
+	{0, ">10m"}, // No upper bound
+}
+
+// durationHistogram holds one counter per entry of durationBuckets
+type durationHistogram [len(durationBuckets)]atomic.Uint64
+
+// RecordConnDuration records how long a relayed connection was open, when it is torn down
+// Safe to call from every relay without locking
+func (s *StatusLogger) RecordConnDuration(d time.Duration) {
+	s.durations[durationBucketIndex(d)].Add(1)
+}
+
+// ConnDurations returns the number of finished connections per duration bucket since startup
+func (s *StatusLogger) ConnDurations() map[string]uint64 {
+	counts := make(map[string]uint64, len(durationBuckets))
+	for i, bucket := range durationBuckets {
+		counts[bucket.label] = s.durations[i].Load()
+	}
+	return counts
+}
+
+// durationBucketIndex maps a duration to its histogram bucket
+func durationBucketIndex(d time.Duration) int {
+	for i, bucket := range durationBuckets {
+		if bucket.max > 0 && d < bucket.max {
+			return i
+		}
+	}
+	return len(durationBuckets) - 1
+}
+
+// formatConnDurations renders the histogram as "bucket=n" pairs in bucket order, or "" before any connection ended
+func (s *StatusLogger) formatConnDurations() string {
+	total := uint64(0)
+	out := ""
+	for i, bucket := range durationBuckets {
+		n := s.durations[i].Load()
+		total += n
+		if out != "" {
+			out += " "
+		}
+		out += fmt.Sprintf("%s=%d", bucket.label, n)
+	}
+	if total == 0 {
+		return ""
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// logRetryInterval is how often a failed log file is retried (e.g. after space is freed)
+const logRetryInterval = time.Minute
+
+// fallbackWriter writes to the log file and drops lines quietly (flagging the status) when writes fail
+// Without it a full disk turns every log call into an error exactly when diagnostics matter most;
+// the lines stay available from the in-memory log (memoryLog)
+type fallbackWriter struct {
+	mu        sync.Mutex
+	file      *os.File
+	failed    bool
+	lastRetry time.Time
+}
+
+// Write never fails: lines that can't reach the file only reach the in-memory log
+func (w *fallbackWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failed && time.Since(w.lastRetry) >= logRetryInterval {
+		w.lastRetry = time.Now()
+		if _, err := w.file.Write(p); err == nil {
+			w.failed = false
+			setLoggingError("")
+			return len(p), nil
+		}
+	}
+
+	if !w.failed {
+		_, err := w.file.Write(p)
+		if err == nil {
+			return len(p), nil
+		}
+		w.failed = true
+		w.lastRetry = time.Now()
+		setLoggingError(describeLogWriteError(err))
+	}
+	return len(p), nil
+}
+
+// healthy reports whether the log file is currently being written
+func (w *fallbackWriter) healthy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.failed
+}
+
+// describeLogWriteError turns a log write failure into the warning shown in the tray
+func describeLogWriteError(err error) string {
+	if errors.Is(err, syscall.ENOSPC) {
+		return "Logging disabled — disk full"
+	}
+	return fmt.Sprintf("Logging disabled — %v", err)
+}
+
+// setLoggingError records the logging problem on the status (empty clears it)
+func setLoggingError(msg string) {
+	if statusLogger != nil {
+		statusLogger.LoggingError = msg
+	}
+}
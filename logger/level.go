@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Level is the minimum severity written to the log
+type Level int
+
+const (
+	LevelInfo  Level = iota // Everything (default)
+	LevelWarn               // Warnings and errors
+	LevelError              // Errors only
+)
+
+// ParseLevel converts a log_level config value ("info", "warn", "error"); ok is false if unknown
+func ParseLevel(s string) (level Level, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "info":
+		return LevelInfo, true
+	case "warn", "warning":
+		return LevelWarn, true
+	case "error":
+		return LevelError, true
+	}
+	return LevelInfo, false
+}
+
+// baseOutput is the destination chosen by InitLogger, before any level filtering
+var baseOutput io.Writer
+
+var (
+	currentLevel = LevelInfo
+	outputMu     sync.Mutex // Guards currentLevel/currentTimestamps while rebuilding the log output
+)
+
+// SetLevel filters log output to lines at or above level
+// A line's level comes from its message prefix (see lineLevel)
+func SetLevel(level Level) {
+	if baseOutput == nil {
+		return
+	}
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	currentLevel = level
+	applyOutputLocked()
+}
+
+// applyOutputLocked installs baseOutput behind the current timestamp and level settings; call with outputMu held
+func applyOutputLocked() {
+	out := baseOutput
+	if currentTimestamps == TimestampISO || currentTimestamps == TimestampISOUTC {
+		out = &isoTimeWriter{w: out, utc: currentTimestamps == TimestampISOUTC}
+	}
+	if currentLevel != LevelInfo {
+		out = &levelWriter{w: out, min: currentLevel}
+	}
+	log.SetOutput(out)
+}
+
+// levelWriter drops log lines below min
+type levelWriter struct {
+	w   io.Writer
+	min Level
+}
+
+func (lw *levelWriter) Write(p []byte) (int, error) {
+	if lineLevel(p) < lw.min {
+		return len(p), nil
+	}
+	return lw.w.Write(p)
+}
+
+// Level prefixes: a message's severity is set by how it starts, not by words elsewhere in it
+// Use Warn and Error, or log.Printf with one of these prefixes; everything else is info
+const (
+	warnPrefix  = "Warning: "
+	errorPrefix = "ERROR: "
+)
+
+var (
+	errorPrefixes = [][]byte{[]byte(errorPrefix), []byte("CRITICAL: ")}
+	warnPrefixes  = [][]byte{[]byte(warnPrefix), []byte("WARNING: ")}
+)
+
+// lineLevel returns a log line's severity from its message prefix
+func lineLevel(line []byte) Level {
+	msg := logMessage(line)
+	for _, prefix := range errorPrefixes {
+		if bytes.HasPrefix(msg, prefix) {
+			return LevelError
+		}
+	}
+	for _, prefix := range warnPrefixes {
+		if bytes.HasPrefix(msg, prefix) {
+			return LevelWarn
+		}
+	}
+	return LevelInfo
+}
+
+// logMessage strips the log package header (date, time, file:line) from line
+func logMessage(line []byte) []byte {
+	for {
+		field, rest, ok := bytes.Cut(line, []byte(" "))
+		if !ok || !isHeaderField(field) {
+			return line
+		}
+		line = rest
+	}
+}
+
+// isHeaderField reports whether field is a date, a time or a "file.go:123:" location
+func isHeaderField(field []byte) bool {
+	if bytes.HasSuffix(field, []byte(":")) && bytes.Contains(field, []byte(".go:")) {
+		return true
+	}
+	if len(field) == 0 || field[0] < '0' || field[0] > '9' {
+		return false
+	}
+	for _, b := range field {
+		if (b < '0' || b > '9') && b != '/' && b != ':' && b != '.' {
+			return false
+		}
+	}
+	return true
+}
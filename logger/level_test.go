@@ -0,0 +1,25 @@
+package logger
+
+import "testing"
+
+func TestLineLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want Level
+	}{
+		{"2026/10/15 21:32:30 quic_client.go:293: Warning: Failed to connect to QUIC server: timeout\n", LevelWarn},
+		{"2026/10/15 21:32:30 main.go:97: ERROR: another instance is running\n", LevelError},
+		{"tray.go:428: WARNING: Rejected CORS origin: https://example.com\n", LevelWarn},
+		{"2026-10-15T21:32:30.123Z relay.go:71: Failed to relay data from client connection 42: EOF\n", LevelInfo},
+		{"2026/10/15 21:32:30 health.go:12: Health check passed, previous ERROR cleared\n", LevelInfo},
+		{"2026/10/15 21:32:30 server_discovery.go:80: 3 servers, Warning: none\n", LevelInfo},
+		{"Warning: no header at all\n", LevelWarn},
+		{"\n", LevelInfo},
+	}
+
+	for _, tt := range tests {
+		if got := lineLevel([]byte(tt.line)); got != tt.want {
+			t.Errorf("lineLevel(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"time"
 )
 
 var (
 	logFile      *os.File
+	logWriter    *fallbackWriter // Wraps logFile in GUI mode; buffers in memory if the file can't be written
 	IsGUIMode    bool
 	statusLogger *StatusLogger
 )
@@ -27,6 +29,38 @@ type StatusLogger struct {
 	IsAuthenticated  bool
 	ServerAddress    string
 	ConnectionUptime time.Time
+	// LastError is the most recent connection/auth failure (cleared on successful auth)
+	LastError           string
+	LastErrorTime       time.Time
+	ConsecutiveFailures int
+	// SessionReconnects counts connections lost after successful auth since startup
+	SessionReconnects int
+	// History is the persisted reconnect log (lifetime total and recent disconnects)
+	History *ReconnectHistory
+	// LoginDeadline is when the pending browser login expires (zero if none pending)
+	LoginDeadline time.Time
+	// NextReconnectAt is when the connect loop's current backoff ends (zero when not waiting to retry)
+	NextReconnectAt time.Time
+	// LoggingError explains why the log file isn't being written (e.g. disk full); empty when logging works
+	LoggingError string
+	// TLSInfo is the negotiated TLS version and cipher suite of the server connection (empty if none)
+	TLSInfo string
+	// LastTraffic is when the server last sent proxy work (connect/data/close), not counting keepalives
+	LastTraffic time.Time
+	// SessionStart is when the current sharing session began (zero when not sharing)
+	SessionStart time.Time
+	// PeakConns is the highest ActiveConns seen this session
+	PeakConns int
+	// LastSessionSummary describes the most recently ended sharing session
+	LastSessionSummary string
+
+	countersMu            sync.Mutex // Guards counter updates from relay goroutines
+	sessionBaseSent       uint64
+	sessionBaseRecv       uint64
+	sessionBaseReconnects int
+
+	messages  messageCounters   // Server messages received per type; see CountMessage
+	durations durationHistogram // Relayed connection lifetimes; see RecordConnDuration
 }
 
 // NewStatusLogger creates a new status logger
@@ -36,6 +70,7 @@ func NewStatusLogger() *StatusLogger {
 		LastUpdate:  time.Now(),
 		Errors:      make([]string, 0, 10),
 		ActiveConns: 0,
+		History:     loadReconnectHistory(),
 	}
 }
 
@@ -61,6 +96,20 @@ func (s *StatusLogger) AddError(err string) {
 	}
 }
 
+// RecordFailure records a connection or authentication failure
+func (s *StatusLogger) RecordFailure(err string) {
+	s.LastError = err
+	s.LastErrorTime = time.Now()
+	s.ConsecutiveFailures++
+	s.AddError(err)
+}
+
+// ResetFailures clears the failure streak after a successful connection
+func (s *StatusLogger) ResetFailures() {
+	s.LastError = ""
+	s.ConsecutiveFailures = 0
+}
+
 // GetStatusText returns formatted status text for tray display
 func (s *StatusLogger) GetStatusText() string {
 	uptime := "N/A"
@@ -75,8 +124,55 @@ func (s *StatusLogger) GetStatusText() string {
 			formatBytes(s.TotalDataRecv))
 	}
 
-	return fmt.Sprintf("Status: %s\nUptime: %s\nConnections: %d%s",
-		s.Status, uptime, s.ActiveConns, dataStr)
+	errorStr := ""
+	if s.LastError != "" {
+		errorStr = fmt.Sprintf("\nLast error: %s (%d consecutive failures)", s.LastError, s.ConsecutiveFailures)
+	}
+	if s.LoggingError != "" {
+		errorStr += fmt.Sprintf("\nWarning: %s (recent logs kept in memory)", s.LoggingError)
+	}
+
+	reconnectStr := ""
+	if s.History.TotalReconnects > 0 {
+		reconnectStr = fmt.Sprintf("\nReconnects: %d this session, %d total", s.SessionReconnects, s.History.TotalReconnects)
+		if n := len(s.History.Recent); n > 0 {
+			last := s.History.Recent[n-1]
+			reconnectStr += fmt.Sprintf("\nLast disconnect: %s (%s)", last.Time.Format("2006-01-02 15:04:05"), last.Reason)
+		}
+	}
+
+	retryStr := ""
+	if remaining := time.Until(s.NextReconnectAt); remaining > 0 {
+		retryStr = fmt.Sprintf("\nNext reconnect in %s", remaining.Round(time.Second))
+	}
+
+	tlsStr := ""
+	if s.TLSInfo != "" {
+		tlsStr = "\nEncryption: " + s.TLSInfo
+	}
+
+	idleStr := ""
+	if s.IsAuthenticated && s.ActiveConns == 0 {
+		// Distinguish a quiet-but-healthy node from a broken one
+		since := s.ConnectionUptime
+		if s.LastTraffic.After(since) {
+			since = s.LastTraffic
+		}
+		if !since.IsZero() && time.Since(since) > time.Minute {
+			idleStr = fmt.Sprintf("\nIdle: no proxy traffic for %s (connection alive)", time.Since(since).Round(time.Minute))
+		}
+	}
+
+	messagesStr := ""
+	if counts := s.formatMessageCounts(); counts != "" {
+		messagesStr = "\nMessages: " + counts
+	}
+	if durations := s.formatConnDurations(); durations != "" {
+		messagesStr += "\nConnection durations: " + durations
+	}
+
+	return fmt.Sprintf("Status: %s\nUptime: %s\nConnections: %d%s%s%s%s%s%s%s",
+		s.Status, uptime, s.ActiveConns, retryStr, tlsStr, idleStr, dataStr, messagesStr, errorStr, reconnectStr)
 }
 
 // formatBytes formats bytes into human-readable format
@@ -94,7 +190,8 @@ func formatBytes(bytes uint64) string {
 }
 
 // InitLogger initializes logging to file (for GUI mode) or stdout (for console mode)
-func InitLogger(guiMode bool) error {
+// teeStdout also copies GUI mode's file log to stdout, for watching it live from a terminal
+func InitLogger(guiMode, teeStdout bool) error {
 	IsGUIMode = guiMode
 	statusLogger = NewStatusLogger()
 
@@ -112,16 +209,24 @@ func InitLogger(guiMode bool) error {
 		}
 
 		logFile = file
+		logWriter = &fallbackWriter{file: file}
 
-		// Set log output to file
-		log.SetOutput(file)
+		// Set log output to file, keeping recent lines in memory too (the file may become unwritable)
+		outputs := []io.Writer{logWriter, recentLogs}
+		if teeStdout {
+			// Last, since MultiWriter stops at the first failing writer (no console in windowsgui builds)
+			outputs = append(outputs, os.Stdout)
+		}
+		baseOutput = io.MultiWriter(outputs...)
+		log.SetOutput(baseOutput)
 		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
 		log.Printf("=== Vyx Client Started (GUI Mode) ===")
 		log.Printf("Log file: %s", logPath)
 	} else {
-		// Console mode: Keep stdout logging
-		log.SetOutput(os.Stdout)
+		// Console mode: Keep stdout logging, with recent lines in memory for TailLogs
+		baseOutput = io.MultiWriter(os.Stdout, recentLogs)
+		log.SetOutput(baseOutput)
 		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 		log.Println("=== Vyx Client Started (Console Mode) ===")
 	}
@@ -148,16 +253,21 @@ func getLogDirectory() string {
 // Info logs an info message and updates status
 func Info(format string, v ...interface{}) {
 	msg := fmt.Sprintf(format, v...)
-	log.Println(msg)
+	log.Output(2, msg)
 	if statusLogger != nil {
 		statusLogger.UpdateStatus(extractStatus(msg))
 	}
 }
 
+// Warn logs a warning; warnings still show with log_level "warn" (-quiet)
+func Warn(format string, v ...interface{}) {
+	log.Output(2, warnPrefix+fmt.Sprintf(format, v...))
+}
+
 // Error logs an error message and adds to error list
 func Error(format string, v ...interface{}) {
 	msg := fmt.Sprintf(format, v...)
-	log.Printf("ERROR: %s", msg)
+	log.Output(2, errorPrefix+msg)
 	if statusLogger != nil {
 		statusLogger.AddError(msg)
 	}
@@ -165,7 +275,7 @@ func Error(format string, v ...interface{}) {
 
 // Debug logs a debug message (only to file, not status)
 func Debug(format string, v ...interface{}) {
-	log.Printf("DEBUG: "+format, v...)
+	log.Output(2, fmt.Sprintf("DEBUG: "+format, v...))
 }
 
 // extractStatus extracts status from log message
@@ -222,9 +332,10 @@ func GetLogPath() string {
 }
 
 // TailLogs returns the last N lines from the log file
+// Without a usable file (console mode, or e.g. disk full) returns the in-memory log instead
 func TailLogs(n int) ([]string, error) {
-	if logFile == nil {
-		return nil, fmt.Errorf("no log file open")
+	if logFile == nil || !logWriter.healthy() {
+		return recentLogs.tail(n), nil
 	}
 
 	// Reopen file for reading
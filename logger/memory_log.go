@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+)
+
+// memoryLogLines is how many recent lines are kept in memory, in every mode
+const memoryLogLines = 500
+
+// memoryLog keeps the most recent log lines so they can be shown without a log file
+// (console mode, or while the file can't be written)
+type memoryLog struct {
+	mu    sync.Mutex
+	lines []string
+	next  int // Slot the next line goes into once lines is full
+}
+
+// recentLogs is teed into the log output by InitLogger
+var recentLogs = &memoryLog{}
+
+// Write records each line in p; it never fails so it can't disturb the real log output
+func (m *memoryLog) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if len(m.lines) < memoryLogLines {
+			m.lines = append(m.lines, line)
+			continue
+		}
+		m.lines[m.next] = line
+		m.next = (m.next + 1) % memoryLogLines
+	}
+	return len(p), nil
+}
+
+// tail returns up to n of the most recent lines, oldest first
+func (m *memoryLog) tail(n int) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ordered := append(append([]string(nil), m.lines[m.next:]...), m.lines[:m.next]...)
+	if len(ordered) > n {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
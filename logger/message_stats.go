@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// messageCounterTypes are the server message types counted individually; anything else is "unknown"
+// A rising unknown count points at a protocol mismatch, a high close count at connection churn
+var messageCounterTypes = [...]string{"connect", "data", "close", "half_close", "ping", "maintenance", "server_shutdown", "unknown"}
+
+// messageCounters holds one counter per entry of messageCounterTypes
+type messageCounters [len(messageCounterTypes)]atomic.Uint64
+
+// CountMessage records one message of msgType received from the server
+// Safe to call from every stream reader without locking
+func (s *StatusLogger) CountMessage(msgType string) {
+	s.messages[messageCounterIndex(msgType)].Add(1)
+}
+
+// MessageCounts returns the number of messages received per type since startup
+func (s *StatusLogger) MessageCounts() map[string]uint64 {
+	counts := make(map[string]uint64, len(messageCounterTypes))
+	for i, msgType := range messageCounterTypes {
+		counts[msgType] = s.messages[i].Load()
+	}
+	return counts
+}
+
+// messageCounterIndex maps a message type to its counter, falling back to "unknown"
+func messageCounterIndex(msgType string) int {
+	for i, known := range messageCounterTypes {
+		if known == msgType {
+			return i
+		}
+	}
+	return len(messageCounterTypes) - 1
+}
+
+// formatMessageCounts renders the non-zero counters as "type=n" pairs in a fixed order
+func (s *StatusLogger) formatMessageCounts() string {
+	out := ""
+	for i, msgType := range messageCounterTypes {
+		n := s.messages[i].Load()
+		if n == 0 {
+			continue
+		}
+		if out != "" {
+			out += " "
+		}
+		out += fmt.Sprintf("%s=%d", msgType, n)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxDisconnectRecords is how many recent disconnects are kept in the history file
+const maxDisconnectRecords = 20
+
+// DisconnectRecord is a single lost connection and why it ended
+type DisconnectRecord struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
+// ReconnectHistory is the reconnect log persisted across restarts in ~/.vyx/reconnects.json
+type ReconnectHistory struct {
+	TotalReconnects int                `json:"total_reconnects"`
+	Recent          []DisconnectRecord `json:"recent"` // Oldest first
+}
+
+// getReconnectHistoryPath returns the path to the reconnect history file
+func getReconnectHistoryPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".vyx", "reconnects.json")
+}
+
+// loadReconnectHistory reads the persisted history (empty if missing or unreadable)
+func loadReconnectHistory() *ReconnectHistory {
+	history := &ReconnectHistory{}
+	data, err := os.ReadFile(getReconnectHistoryPath())
+	if err != nil {
+		return history
+	}
+	if err := json.Unmarshal(data, history); err != nil {
+		log.Printf("Warning: Ignoring unreadable reconnect history: %v", err)
+		return &ReconnectHistory{}
+	}
+	return history
+}
+
+// save writes the history to disk
+func (h *ReconnectHistory) save() error {
+	path := getReconnectHistoryPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// RecordReconnect counts a lost connection for this session and in the persisted history
+func (s *StatusLogger) RecordReconnect(reason string) {
+	s.SessionReconnects++
+
+	s.History.TotalReconnects++
+	s.History.Recent = append(s.History.Recent, DisconnectRecord{Time: time.Now(), Reason: reason})
+	if len(s.History.Recent) > maxDisconnectRecords {
+		s.History.Recent = s.History.Recent[len(s.History.Recent)-maxDisconnectRecords:]
+	}
+
+	if err := s.History.save(); err != nil {
+		log.Printf("Warning: Failed to save reconnect history: %v", err)
+	}
+}
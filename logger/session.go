@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// AddDataSent counts bytes relayed from destinations to the server
+func (s *StatusLogger) AddDataSent(n int) {
+	s.countersMu.Lock()
+	s.TotalDataSent += uint64(n)
+	s.countersMu.Unlock()
+}
+
+// AddDataRecv counts bytes relayed from the server to destinations
+func (s *StatusLogger) AddDataRecv(n int) {
+	s.countersMu.Lock()
+	s.TotalDataRecv += uint64(n)
+	s.countersMu.Unlock()
+}
+
+// SetActiveConns records the number of open relayed connections and tracks the session peak
+func (s *StatusLogger) SetActiveConns(n int) {
+	s.countersMu.Lock()
+	s.ActiveConns = n
+	if n > s.PeakConns {
+		s.PeakConns = n
+	}
+	s.countersMu.Unlock()
+}
+
+// StartSession begins a sharing session; counters in the summary are relative to this point
+func (s *StatusLogger) StartSession() {
+	s.countersMu.Lock()
+	defer s.countersMu.Unlock()
+
+	if !s.SessionStart.IsZero() {
+		return // Already sharing
+	}
+	s.SessionStart = time.Now()
+	s.PeakConns = s.ActiveConns
+	s.sessionBaseSent = s.TotalDataSent
+	s.sessionBaseRecv = s.TotalDataRecv
+	s.sessionBaseReconnects = s.SessionReconnects
+}
+
+// EndSession closes the current sharing session and returns its summary ("" if none was running)
+// The summary is also kept in LastSessionSummary for the tray
+func (s *StatusLogger) EndSession() string {
+	s.countersMu.Lock()
+	defer s.countersMu.Unlock()
+
+	if s.SessionStart.IsZero() {
+		return ""
+	}
+
+	summary := fmt.Sprintf("Session summary: shared for %s, relayed ↑%s ↓%s, peak %d concurrent connections, %d reconnects",
+		time.Since(s.SessionStart).Round(time.Second),
+		formatBytes(s.TotalDataSent-s.sessionBaseSent),
+		formatBytes(s.TotalDataRecv-s.sessionBaseRecv),
+		s.PeakConns,
+		s.SessionReconnects-s.sessionBaseReconnects)
+
+	s.SessionStart = time.Time{}
+	s.LastSessionSummary = summary
+	return summary
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// TimestampFormat selects how the time at the start of each log line is written
+type TimestampFormat int
+
+const (
+	TimestampLocal  TimestampFormat = iota // "2006/01/02 15:04:05" in local time (default)
+	TimestampUTC                           // Same layout in UTC
+	TimestampISO                           // ISO 8601 / RFC 3339 with milliseconds and the local offset
+	TimestampISOUTC                        // ISO 8601 in UTC ("...Z"), for correlating logs across a fleet
+)
+
+// isoTimeLayout is RFC 3339 with milliseconds; Z07:00 gives "Z" in UTC and "+02:00" otherwise
+const isoTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// ParseTimestampFormat converts a log_timestamps config value ("local", "utc", "iso", "iso-utc"); ok is false if unknown
+func ParseTimestampFormat(s string) (format TimestampFormat, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "local":
+		return TimestampLocal, true
+	case "utc":
+		return TimestampUTC, true
+	case "iso":
+		return TimestampISO, true
+	case "iso-utc":
+		return TimestampISOUTC, true
+	}
+	return TimestampLocal, false
+}
+
+// currentTimestamps is the format applied by SetTimestampFormat
+var currentTimestamps = TimestampLocal
+
+// SetTimestampFormat changes the timestamp of subsequent log lines
+// The standard layouts use the log package's flags; ISO layouts are written by isoTimeWriter instead
+func SetTimestampFormat(format TimestampFormat) {
+	if baseOutput == nil {
+		return
+	}
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	currentTimestamps = format
+	switch format {
+	case TimestampUTC:
+		log.SetFlags(log.Ldate | log.Ltime | log.LUTC | log.Lshortfile)
+	case TimestampISO, TimestampISOUTC:
+		log.SetFlags(log.Lshortfile)
+	default:
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	}
+	applyOutputLocked()
+}
+
+// isoTimeWriter prefixes each log line with an ISO 8601 timestamp
+type isoTimeWriter struct {
+	w   io.Writer
+	utc bool
+}
+
+func (t *isoTimeWriter) Write(p []byte) (int, error) {
+	now := time.Now()
+	if t.utc {
+		now = now.UTC()
+	}
+	line := make([]byte, 0, len(isoTimeLayout)+1+len(p))
+	line = now.AppendFormat(line, isoTimeLayout)
+	line = append(line, ' ')
+	line = append(line, p...)
+	if _, err := t.w.Write(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
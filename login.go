@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"client/auth"
+	"client/config"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// runLoginCommand handles `vyx login`: email/password login for machines where no browser can be opened
+func runLoginCommand() int {
+	if _, err := config.LoadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not load config: %v\n", err)
+		return 1
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("Email: ")
+	email, _ := reader.ReadString('\n')
+	password := readPassword(reader)
+
+	email = strings.TrimSpace(email)
+	if email == "" || password == "" {
+		fmt.Fprintln(os.Stderr, "Email and password are required")
+		return 1
+	}
+
+	if err := auth.Login(email, password); errors.Is(err, config.ErrTokenNotPersisted) {
+		// The login only lives as long as this process, which is about to exit
+		fmt.Fprintf(os.Stderr, "Logged in, but the token could not be stored: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Unlock your keyring and try again, or set %s=file to store it in ~/.vyx/secrets.\n", config.SecretBackendEnv)
+		return 1
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Login failed: %v\n", err)
+		return 1
+	}
+	if err := config.MarkFirstRunCompleted(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save settings: %v\n", err)
+	}
+
+	fmt.Printf("Logged in as %s. Start Vyx (or restart it if it is already running) to begin sharing.\n", email)
+	return 0
+}
+
+// readPassword prompts for the password without echoing it when stdin is a terminal
+// Piped input (scripts) is read as a plain line from reader
+func readPassword(reader *bufio.Reader) string {
+	fmt.Print("Password: ")
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		password, _ := reader.ReadString('\n')
+		return strings.TrimRight(password, "\r\n")
+	}
+
+	password, _ := term.ReadPassword(fd)
+	fmt.Println() // The newline typed by the user isn't echoed either
+	return string(password)
+}
@@ -6,10 +6,13 @@ import (
 	"client/logger"
 	"client/platform"
 	"client/ui"
+	"client/version"
 	_ "embed"
 	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/getlantern/systray"
@@ -19,30 +22,61 @@ import (
 var iconData []byte
 
 const (
-	VERSION = "v0.1.1" // Semver format (must start with 'v')
 	WEBSITE = "https://vyx.network"
 )
 
+// exitAlreadyRunning is the exit code when another instance holds the lock
+// The Linux service unit uses it (RestartPreventExitStatus) to avoid restart loops
+const exitAlreadyRunning = 3
+
 var (
 	guiMode     = flag.Bool("gui", false, "Run in GUI mode (no console window, logs to file)")
 	consoleMode = flag.Bool("console", false, "Run in console mode with visible window")
 	debugMode   = flag.Bool("debug", false, "Run in debug mode (connect to localhost servers: API at 127.0.0.1:8080, QUIC at 127.0.0.1:8443)")
+	assumeYes   = flag.Bool("yes", false, "Skip confirmation prompts (for `reset`)")
+	quiet       = flag.Bool("quiet", false, "Only log warnings and errors (overrides log_level in config)")
+	forceServer = flag.String("server", "", "Connect only to this server (host:port), skipping server discovery (for support diagnostics)")
+	logUTC      = flag.Bool("log-utc", false, "Log ISO 8601 UTC timestamps (overrides log_timestamps in config)")
+	logStdout   = flag.Bool("log-stdout", false, "In GUI mode, also print the log to stdout (for watching it from a terminal)")
+	perSession  = flag.Bool("per-session", false, "Allow one instance per login session instead of one per user (Windows: Fast User Switching, RDP)")
 )
 
 func main() {
 	flag.Parse()
 
+	// SUBCOMMANDS: `vyx reset` clears credentials and settings, `vyx login` logs in without a browser,
+	// `vyx export-config`/`import-config` copy settings between machines, `vyx test-dial` checks a destination,
+	// `vyx config` prints the effective settings
+	switch flag.Arg(0) {
+	case "reset":
+		os.Exit(runResetCommand(*assumeYes))
+	case "login":
+		os.Exit(runLoginCommand())
+	case "export-config":
+		os.Exit(runExportConfigCommand(flag.Arg(1)))
+	case "import-config":
+		os.Exit(runImportConfigCommand(flag.Arg(1)))
+	case "test-dial":
+		os.Exit(runTestDialCommand(flag.Arg(1)))
+	case "config":
+		os.Exit(runConfigCommand())
+	}
+
 	// Determine if running in GUI mode
 	// Default to GUI mode if built with -H windowsgui, otherwise console mode
 	isGUIMode := *guiMode || (!*consoleMode && isBuiltAsGUI())
 
-	// Initialize logger (file for GUI mode, stdout for console mode)
-	if err := logger.InitLogger(isGUIMode); err != nil {
+	// Initialize logger (file for GUI mode, stdout for console mode; -log-stdout gives GUI mode both)
+	if err := logger.InitLogger(isGUIMode, *logStdout); err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	if *logUTC {
+		// Applied before anything past the startup banner is logged
+		logger.SetTimestampFormat(logger.TimestampISOUTC)
+	}
 	defer logger.Close()
 
-	logger.Info("Vyx Client v%s starting...", VERSION)
+	logger.Info("Vyx Client %s starting...", version.String())
 	if isGUIMode {
 		logger.Info("Running in GUI mode - logs at: %s", logger.GetLogPath())
 	} else {
@@ -51,11 +85,17 @@ func main() {
 
 	// SINGLE INSTANCE LOCK: Prevent multiple instances from running on the same device
 	// This ensures the device doesn't appear multiple times in the dashboard
-	instanceLock, err := platform.AcquireInstanceLock()
+	instanceLock, err := platform.AcquireInstanceLock(*perSession)
 	if err != nil {
+		if platform.IsServiceRunning() {
+			// Linux autostart service is already sharing in the background - nothing to do
+			logger.Info("Vyx is already running as the vyx.service background service - sharing continues there")
+			log.Println("Manage it with: sudo systemctl stop|start|status vyx.service")
+			os.Exit(exitAlreadyRunning)
+		}
 		logger.Error("Another instance is already running")
-		log.Fatalf("ERROR: %v\n\nPlease close the existing instance before starting a new one.", err)
-		return
+		log.Printf("ERROR: %v\n\nPlease close the existing instance before starting a new one.", err)
+		os.Exit(exitAlreadyRunning)
 	}
 	defer instanceLock.Release()
 	logger.Info("Instance lock acquired - this is the only running instance")
@@ -65,22 +105,95 @@ func main() {
 	if err != nil {
 		logger.Error("Could not load config: %v", err)
 	} else {
-		logger.Info("Config loaded - IsLoggedIn: %v, Email: %s", config.IsLoggedIn(), cfg.Email)
+		logger.Info("Config loaded - IsLoggedIn: %v, Email: %s", config.IsLoggedIn(), config.RedactPersonal(cfg.Email))
+	}
+
+	// LOG LEVEL: -quiet wins over log_level from config
+	if *quiet {
+		logger.SetLevel(logger.LevelWarn)
+	} else if cfg != nil {
+		level, _ := logger.ParseLevel(cfg.LogLevel)
+		logger.SetLevel(level)
+	}
+
+	// LOG TIMESTAMPS: -log-utc wins over log_timestamps from config
+	if !*logUTC && cfg != nil {
+		format, _ := logger.ParseTimestampFormat(cfg.LogTimestamps)
+		logger.SetTimestampFormat(format)
 	}
 
 	// Enable debug mode if flag is set
 	if *debugMode {
 		logger.Info("DEBUG MODE ENABLED - Connecting to localhost servers (API: 127.0.0.1:8080, QUIC: 127.0.0.1:8443)")
-		cfg.DebugMode = true
-		config.GlobalConfig.DebugMode = true
+		config.ForceDebugMode()
+	}
+
+	// FORCED SERVER: Support can pin a specific server without editing config
+	if *forceServer != "" {
+		if *debugMode {
+			log.Println("Warning: -server is ignored in debug mode (debug always uses 127.0.0.1:8443)")
+		} else {
+			conn.ForceServer(*forceServer)
+			// Logged as a warning so it still shows with -quiet
+			log.Printf("Warning: FORCED SERVER in effect - server discovery disabled, connecting only to %s", *forceServer)
+		}
 	}
 
+	// HEALTH CHECK: Optional /healthz endpoint for container deployments
+	if cfg != nil && cfg.HealthAddr != "" {
+		if err := conn.StartHealthServer(cfg.HealthAddr); err != nil {
+			logger.Error("Failed to start health endpoint: %v", err)
+		}
+	}
+
+	// TELEMETRY: Opt-in anonymous usage stats (reporter idles while disabled)
+	conn.StartTelemetryReporter()
+	conn.StartReportQueueDrainer()
+
+	// TOKEN CHECK: Opt-in periodic re-validation catches tokens revoked server-side
+	conn.StartTokenValidator()
+
+	// HOT RELOAD: Apply edits to config.json without restarting
+	go config.WatchConfig(func(old, updated config.Config) {
+		if !*quiet && old.LogLevel != updated.LogLevel {
+			level, _ := logger.ParseLevel(updated.LogLevel)
+			logger.SetLevel(level)
+		}
+		if !*logUTC && old.LogTimestamps != updated.LogTimestamps {
+			format, _ := logger.ParseTimestampFormat(updated.LogTimestamps)
+			logger.SetTimestampFormat(format)
+		}
+		conn.HandleConfigChange(old, updated)
+	})
+
 	// Start QUIC connection
 	go conn.ConnectQuicServer()
 
+	// HEADLESS FALLBACK: Without a tray host systray shows nothing and the app looks dead
+	if available, reason := platform.TrayAvailable(); !available {
+		logger.Error("System tray unavailable: %s", reason)
+		log.Println("Running headless - bandwidth sharing continues without a tray icon. Press Ctrl+C to quit.")
+		runHeadless()
+		return
+	}
+
 	systray.Run(onReady, onExit)
 }
 
+// runHeadless runs startup tasks without a tray and blocks until interrupted
+func runHeadless() {
+	startBackgroundTasks()
+
+	if !config.IsLoggedIn() {
+		logger.Error("Not logged in - run `vyx-client login` in a terminal, or log in on a desktop session first.")
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	onExit()
+}
+
 // isBuiltAsGUI checks if the binary was built with -H windowsgui (no console on Windows)
 func isBuiltAsGUI() bool {
 	// On Windows, if built with -H windowsgui, there's no stdout
@@ -90,12 +203,38 @@ func isBuiltAsGUI() bool {
 }
 
 func onExit() {
+	// Headless runs end here without Stop Sharing, so summarize the session now
+	if summary := logger.GetStatus().EndSession(); summary != "" {
+		log.Println(summary)
+	}
 	log.Println("Application exiting gracefully...")
 }
 
 func onReady() {
 	ui.SetupTray(WEBSITE, iconData)
 
+	// RE-LOGIN: Reopen the browser login if the server rejects the stored token mid-session
+	conn.SetTokenRejectedHandler(ui.TriggerAutoLogin)
+
+	startBackgroundTasks()
+
+	// AUTO-LOGIN: On first run only, automatically open browser for setup
+	// Later starts leave login to the tray's "Login" item instead of popping a browser every time
+	if !config.IsLoggedIn() && config.IsFirstRun() {
+		logger.Info("First time setup - opening browser for login...")
+		if err := config.MarkFirstRunCompleted(); err != nil {
+			logger.Error("Failed to save first-run state: %v", err)
+		}
+		// Delay slightly to ensure tray is fully initialized
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			ui.TriggerAutoLogin()
+		}()
+	}
+}
+
+// startBackgroundTasks applies the autostart preference and checks for updates
+func startBackgroundTasks() {
 	// AUTO-START: Enable autostart based on user preference (default: enabled)
 	// User can toggle via tray menu
 	if config.GetAutoStartEnabled() {
@@ -116,14 +255,4 @@ func onReady() {
 	if err := AutoUpdate(); err != nil {
 		log.Println(err)
 	}
-
-	// AUTO-LOGIN: If not logged in, automatically open browser for first-time setup
-	if !config.IsLoggedIn() {
-		logger.Info("First time setup - opening browser for login...")
-		// Delay slightly to ensure tray is fully initialized
-		go func() {
-			time.Sleep(500 * time.Millisecond)
-			ui.TriggerAutoLogin()
-		}()
-	}
 }
@@ -22,7 +22,8 @@ func EnableAutoStart() error {
 		return err
 	}
 
-	executable, err := os.Executable()
+	// Resolve symlinks so the LaunchAgent survives the link being moved or repointed
+	executable, err := ExecutablePath()
 	if err != nil {
 		return err
 	}
@@ -14,6 +14,8 @@ After=network.target
 [Service]
 ExecStart=/usr/local/bin/Vyx
 Restart=always
+# Exit code 3 means another instance (e.g. the GUI) holds the instance lock - don't restart-loop
+RestartPreventExitStatus=3
 User=%s
 Environment=PATH=/usr/local/bin:/usr/bin
 WorkingDirectory=%s
@@ -31,12 +33,21 @@ func EnableAutoStart() error {
 		return err
 	}
 
-	executable, err := os.Executable()
+	// Resolve symlinks: hard-linking a symlink links the symlink itself, not the binary
+	executable, err := ExecutablePath()
 	if err != nil {
 		return err
 	}
 
-	os.Link(executable, binPath)
+	// Replace a link left by an older install so the service doesn't start a stale binary
+	if existing, statErr := os.Stat(binPath); statErr == nil {
+		if current, err := os.Stat(executable); err == nil && !os.SameFile(existing, current) {
+			os.Remove(binPath)
+		}
+	}
+	if err := os.Link(executable, binPath); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("linking %s to %s: %w", executable, binPath, err)
+	}
 
 	serviceContent := fmt.Sprintf(serviceTemplate, usr.Username, usr.HomeDir)
 
@@ -50,14 +61,13 @@ func EnableAutoStart() error {
 	if err != nil {
 		return err
 	}
+	// Enable only: this process is already running and holds the instance lock,
+	// so starting the service now would just spawn a duplicate that exits
+	// The service takes over on next boot
 	err = exec.Command("systemctl", "enable", "vyx.service").Run()
 	if err != nil {
 		return err
 	}
-	err = exec.Command("systemctl", "start", "vyx.service").Run()
-	if err != nil {
-		return err
-	}
 
 	return nil
 }
@@ -88,3 +98,8 @@ func IsAutoStartEnabled() bool {
 	err := exec.Command("systemctl", "is-enabled", "vyx.service").Run()
 	return err == nil
 }
+
+// IsServiceRunning reports whether the systemd autostart service is currently active
+func IsServiceRunning() bool {
+	return exec.Command("systemctl", "is-active", "--quiet", "vyx.service").Run() == nil
+}
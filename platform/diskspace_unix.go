@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package platform
+
+import "syscall"
+
+// FreeDiskSpace returns the bytes available to this user on the filesystem containing dir
+func FreeDiskSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	// Bavail excludes blocks reserved for root, which a non-root update can't use
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package platform
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceEx = kernel32.NewProc("GetDiskFreeSpaceExW")
+
+// FreeDiskSpace returns the bytes available to this user on the volume containing dir
+func FreeDiskSpace(dir string) (uint64, error) {
+	dirPtr, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	// Free bytes available to the caller (respects disk quotas)
+	var freeBytes uint64
+	ret, _, err := getDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(dirPtr)),
+		uintptr(unsafe.Pointer(&freeBytes)),
+		0,
+		0,
+	)
+	if ret == 0 {
+		return 0, err
+	}
+	return freeBytes, nil
+}
@@ -0,0 +1,25 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExecutablePath returns the real path of the running binary with symlinks resolved
+// os.Executable can return a symlink (package managers, `ln -s`) or, on Linux, a path
+// marked " (deleted)" after the file was replaced; writing to either hits the wrong file
+func ExecutablePath() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	executable = strings.TrimSuffix(executable, " (deleted)")
+
+	resolved, err := filepath.EvalSymlinks(executable)
+	if err != nil {
+		// Path no longer exists or can't be resolved - the unresolved path is the best we have
+		return executable, nil
+	}
+	return resolved, nil
+}
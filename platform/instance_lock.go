@@ -10,18 +10,21 @@ import (
 type InstanceLock struct {
 	lockFile *os.File
 	lockPath string
+	release  func()
 }
 
 // AcquireInstanceLock attempts to acquire a single-instance lock
 // Returns an InstanceLock that should be released on exit, or an error if another instance is running
-func AcquireInstanceLock() (*InstanceLock, error) {
+// By default one instance runs per user across all login sessions; perSession allows one per session instead
+// (Windows only, e.g. the same account in several RDP sessions)
+func AcquireInstanceLock(perSession bool) (*InstanceLock, error) {
 	// Get lock file path in config directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
-	lockPath := filepath.Join(homeDir, ".vyx", "instance.lock")
+	lockPath := filepath.Join(homeDir, ".vyx", "instance"+lockScope(perSession)+".lock")
 
 	// Create .vyx directory if it doesn't exist
 	lockDir := filepath.Dir(lockPath)
@@ -36,7 +39,8 @@ func AcquireInstanceLock() (*InstanceLock, error) {
 	}
 
 	// Try to acquire exclusive lock (platform-specific implementation in _unix.go or _windows.go)
-	if err := acquireLock(lockFile); err != nil {
+	release, err := acquireLock(lockFile, lockPath)
+	if err != nil {
 		lockFile.Close()
 		return nil, fmt.Errorf("another instance of Vyx is already running")
 	}
@@ -51,6 +55,7 @@ func AcquireInstanceLock() (*InstanceLock, error) {
 	return &InstanceLock{
 		lockFile: lockFile,
 		lockPath: lockPath,
+		release:  release,
 	}, nil
 }
 
@@ -61,7 +66,7 @@ func (l *InstanceLock) Release() error {
 	}
 
 	// Release the lock (platform-specific)
-	releaseLock(l.lockFile)
+	l.release()
 
 	// Close and remove lock file
 	l.lockFile.Close()
@@ -71,5 +76,5 @@ func (l *InstanceLock) Release() error {
 }
 
 // Platform-specific functions (implemented in _unix.go and _windows.go):
-// - acquireLock(file *os.File) error
-// - releaseLock(file *os.File)
+// - lockScope(perSession bool) string
+// - acquireLock(file *os.File, lockPath string) (release func(), err error)
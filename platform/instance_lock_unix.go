@@ -8,19 +8,22 @@ import (
 	"syscall"
 )
 
+// lockScope returns the lock name suffix; Unix always allows one instance per user across sessions
+func lockScope(perSession bool) string {
+	return ""
+}
+
 // acquireLock attempts to acquire an exclusive lock on Unix/Linux/macOS
-func acquireLock(file *os.File) error {
+// The returned function releases it
+func acquireLock(file *os.File, lockPath string) (func(), error) {
 	// Try to acquire exclusive lock (non-blocking)
 	// LOCK_EX = exclusive lock, LOCK_NB = non-blocking
 	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
-}
-
-// releaseLock releases the exclusive lock on Unix/Linux/macOS
-func releaseLock(file *os.File) {
-	// LOCK_UN = unlock
-	syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	return func() {
+		// LOCK_UN = unlock
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	}, nil
 }
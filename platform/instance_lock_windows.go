@@ -4,61 +4,55 @@
 package platform
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"strings"
 	"syscall"
 	"unsafe"
 )
 
 var (
-	kernel32     = syscall.NewLazyDLL("kernel32.dll")
-	lockFileEx   = kernel32.NewProc("LockFileEx")
-	unlockFileEx = kernel32.NewProc("UnlockFileEx")
+	kernel32              = syscall.NewLazyDLL("kernel32.dll")
+	createMutex           = kernel32.NewProc("CreateMutexW")
+	processIdToSessionId  = kernel32.NewProc("ProcessIdToSessionId")
+	errorAlreadyExists    = syscall.Errno(183) // ERROR_ALREADY_EXISTS
+	instanceMutexBaseName = `Global\Vyx-Client-`
 )
 
-const (
-	LOCKFILE_EXCLUSIVE_LOCK   = 0x00000002
-	LOCKFILE_FAIL_IMMEDIATELY = 0x00000001
-)
-
-// acquireLock attempts to acquire an exclusive lock on Windows
-func acquireLock(file *os.File) error {
-	// Get file handle
-	handle := syscall.Handle(file.Fd())
-
-	// Create overlapped structure (required for LockFileEx)
-	var overlapped syscall.Overlapped
-
-	// Try to acquire exclusive lock (non-blocking)
-	// LOCKFILE_EXCLUSIVE_LOCK | LOCKFILE_FAIL_IMMEDIATELY
-	flags := uint32(LOCKFILE_EXCLUSIVE_LOCK | LOCKFILE_FAIL_IMMEDIATELY)
-
-	ret, _, err := lockFileEx.Call(
-		uintptr(handle),
-		uintptr(flags),
-		uintptr(0), // reserved
-		uintptr(1), // lock 1 byte
-		uintptr(0), // high order 32 bits of length
-		uintptr(unsafe.Pointer(&overlapped)),
-	)
-
+// lockScope returns the lock name suffix: the session ID when perSession, otherwise empty (one per user)
+func lockScope(perSession bool) string {
+	if !perSession {
+		return ""
+	}
+	var sessionID uint32
+	ret, _, _ := processIdToSessionId.Call(uintptr(os.Getpid()), uintptr(unsafe.Pointer(&sessionID)))
 	if ret == 0 {
-		return fmt.Errorf("failed to acquire lock: %w", err)
+		return "" // Unknown session - fall back to one instance per user
 	}
-
-	return nil
+	return fmt.Sprintf("-session%d", sessionID)
 }
 
-// releaseLock releases the exclusive lock on Windows
-func releaseLock(file *os.File) {
-	handle := syscall.Handle(file.Fd())
-	var overlapped syscall.Overlapped
+// acquireLock attempts to acquire the instance lock on Windows
+// A named mutex is used instead of LockFileEx, which is unreliable across Fast User Switching and RDP sessions
+// (e.g. roaming or redirected profiles); the kernel drops the mutex when the process exits, even on a crash
+// The name is derived from lockPath, so it is per user profile (and per session when lockPath is)
+func acquireLock(file *os.File, lockPath string) (func(), error) {
+	sum := sha256.Sum256([]byte(strings.ToLower(lockPath)))
+	name, err := syscall.UTF16PtrFromString(instanceMutexBaseName + hex.EncodeToString(sum[:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	handle, _, err := createMutex.Call(0, 0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return nil, fmt.Errorf("failed to create instance mutex: %w", err)
+	}
+	if err == errorAlreadyExists {
+		syscall.CloseHandle(syscall.Handle(handle))
+		return nil, fmt.Errorf("instance mutex already exists")
+	}
 
-	unlockFileEx.Call(
-		uintptr(handle),
-		uintptr(0), // reserved
-		uintptr(1), // unlock 1 byte
-		uintptr(0), // high order 32 bits of length
-		uintptr(unsafe.Pointer(&overlapped)),
-	)
+	return func() { syscall.CloseHandle(syscall.Handle(handle)) }, nil
 }
@@ -0,0 +1,20 @@
+package platform
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// OSVersion returns the macOS version from sw_vers, e.g. "macOS 14.5 (23F79)"
+func OSVersion() string {
+	version, err := exec.Command("sw_vers", "-productVersion").Output()
+	if err != nil {
+		return "macOS"
+	}
+
+	result := "macOS " + strings.TrimSpace(string(version))
+	if build, err := exec.Command("sw_vers", "-buildVersion").Output(); err == nil {
+		result += " (" + strings.TrimSpace(string(build)) + ")"
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// OSVersion returns the distribution and kernel version, e.g. "Ubuntu 24.04 LTS (kernel 6.8.0-31-generic)"
+func OSVersion() string {
+	name := readOSReleaseName()
+	kernel := kernelRelease()
+
+	switch {
+	case name != "" && kernel != "":
+		return name + " (kernel " + kernel + ")"
+	case name != "":
+		return name
+	case kernel != "":
+		return "Linux " + kernel
+	default:
+		return "Linux"
+	}
+}
+
+// readOSReleaseName returns PRETTY_NAME (or NAME VERSION_ID) from os-release
+func readOSReleaseName() string {
+	for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		values := make(map[string]string)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), "=")
+			if !ok {
+				continue
+			}
+			values[key] = strings.Trim(value, `"'`)
+		}
+		file.Close()
+
+		if values["PRETTY_NAME"] != "" {
+			return values["PRETTY_NAME"]
+		}
+		if values["NAME"] != "" {
+			return strings.TrimSpace(values["NAME"] + " " + values["VERSION_ID"])
+		}
+	}
+	return ""
+}
+
+// kernelRelease returns the kernel release as reported by uname -r
+func kernelRelease() string {
+	var uts syscall.Utsname
+	if err := syscall.Uname(&uts); err != nil {
+		return ""
+	}
+
+	release := make([]byte, 0, len(uts.Release))
+	for _, c := range uts.Release {
+		if c == 0 {
+			break
+		}
+		release = append(release, byte(c))
+	}
+	return string(release)
+}
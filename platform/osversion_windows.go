@@ -0,0 +1,40 @@
+//go:build windows
+// +build windows
+
+package platform
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// OSVersion returns the Windows version, e.g. "Windows 11 Pro 23H2 (10.0.22631)"
+func OSVersion() string {
+	// RtlGetVersion reports the real version (not affected by compatibility shims)
+	info := windows.RtlGetVersion()
+	version := fmt.Sprintf("%d.%d.%d", info.MajorVersion, info.MinorVersion, info.BuildNumber)
+
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows NT\CurrentVersion`, registry.QUERY_VALUE)
+	if err != nil {
+		return "Windows " + version
+	}
+	defer key.Close()
+
+	productName, _, _ := key.GetStringValue("ProductName")
+	displayVersion, _, _ := key.GetStringValue("DisplayVersion")
+
+	// Windows 11 still reports "Windows 10" in ProductName; build 22000+ is Windows 11
+	if info.MajorVersion == 10 && info.BuildNumber >= 22000 && strings.HasPrefix(productName, "Windows 10") {
+		productName = "Windows 11" + strings.TrimPrefix(productName, "Windows 10")
+	}
+	if productName == "" {
+		productName = "Windows"
+	}
+	if displayVersion != "" {
+		return fmt.Sprintf("%s %s (%s)", productName, displayVersion, version)
+	}
+	return fmt.Sprintf("%s (%s)", productName, version)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package platform
+
+// IsServiceRunning reports whether a background autostart service is running
+// Only Linux autostart runs as a separate service; elsewhere the login item is this app
+func IsServiceRunning() bool {
+	return false
+}
@@ -0,0 +1,35 @@
+package platform
+
+import (
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// statusNotifierWatcher is the D-Bus name owned by AppIndicator/StatusNotifier hosts
+const statusNotifierWatcher = "org.kde.StatusNotifierWatcher"
+
+// TrayAvailable reports whether a system tray host is available to show the tray icon
+// On Linux this requires a graphical session and a StatusNotifier host on the session bus
+// (GNOME only provides one with the AppIndicator extension installed)
+func TrayAvailable() (bool, string) {
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return false, "no graphical session (DISPLAY/WAYLAND_DISPLAY not set)"
+	}
+
+	bus, err := dbus.SessionBus()
+	if err != nil {
+		return false, "D-Bus session bus unavailable: " + err.Error()
+	}
+
+	var hasOwner bool
+	err = bus.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, statusNotifierWatcher).Store(&hasOwner)
+	if err != nil {
+		return false, "could not query StatusNotifier host: " + err.Error()
+	}
+	if !hasOwner {
+		return false, "no StatusNotifier/AppIndicator host running (on GNOME install the AppIndicator extension)"
+	}
+
+	return true, ""
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package platform
+
+// TrayAvailable reports whether a system tray host is available to show the tray icon
+// Windows and macOS always provide a notification area / menu bar
+func TrayAvailable() (bool, string) {
+	return true, ""
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"client/config"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runResetCommand handles `vyx reset`: clears the token and config.json after confirmation
+func runResetCommand(skipConfirm bool) int {
+	fmt.Println("This will log you out and reset all Vyx settings to defaults.")
+	fmt.Println("Quit any running Vyx instance first, or it may write its old settings back.")
+
+	if !skipConfirm {
+		fmt.Print("Continue? [y/N]: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("Reset cancelled")
+			return 1
+		}
+	}
+
+	if err := config.ResetConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Reset failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Println("Settings reset. Start Vyx and log in again.")
+	return 0
+}
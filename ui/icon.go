@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+)
+
+var (
+	icoMagic = []byte{0, 0, 1, 0}
+	pngMagic = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// checkTrayIcon reports why icon can't be used as the tray icon on this platform (nil if it looks usable)
+// Windows only accepts .ico data; macOS and Linux take ICO or PNG
+func checkTrayIcon(icon []byte) error {
+	switch {
+	case len(icon) == 0:
+		return fmt.Errorf("icon data is empty")
+	case bytes.HasPrefix(icon, icoMagic):
+		return checkICO(icon)
+	case bytes.HasPrefix(icon, pngMagic):
+		if runtime.GOOS == "windows" {
+			return fmt.Errorf("PNG icon data is not supported on Windows")
+		}
+		if _, err := png.DecodeConfig(bytes.NewReader(icon)); err != nil {
+			return fmt.Errorf("invalid PNG icon: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("icon data is neither ICO nor PNG")
+}
+
+// checkICO validates the ICO directory: at least one image, each lying within the data
+func checkICO(icon []byte) error {
+	if len(icon) < 6 {
+		return fmt.Errorf("truncated ICO header")
+	}
+	count := int(binary.LittleEndian.Uint16(icon[4:6]))
+	if count == 0 {
+		return fmt.Errorf("ICO contains no images")
+	}
+	if len(icon) < 6+16*count {
+		return fmt.Errorf("truncated ICO directory")
+	}
+	for i := 0; i < count; i++ {
+		entry := icon[6+16*i:]
+		size := binary.LittleEndian.Uint32(entry[8:12])
+		offset := binary.LittleEndian.Uint32(entry[12:16])
+		if size == 0 || uint64(offset)+uint64(size) > uint64(len(icon)) {
+			return fmt.Errorf("ICO image %d lies outside the data", i)
+		}
+	}
+	return nil
+}
+
+// fallbackTrayIcon generates a plain monochrome disc, so a broken embedded icon still leaves a usable tray
+// Black on transparent also works as a macOS template icon; Windows gets it wrapped in an ICO container
+func fallbackTrayIcon() []byte {
+	const size = 32
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	center, radius := float64(size-1)/2, float64(size)/2-2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)-center, float64(y)-center
+			if dx*dx+dy*dy <= radius*radius {
+				img.Set(x, y, color.NRGBA{A: 255})
+			}
+		}
+	}
+
+	var pngData bytes.Buffer
+	png.Encode(&pngData, img)
+	if runtime.GOOS != "windows" {
+		return pngData.Bytes()
+	}
+
+	// ICO with a single PNG-compressed image (supported since Windows Vista)
+	var ico bytes.Buffer
+	ico.Write(icoMagic)
+	binary.Write(&ico, binary.LittleEndian, uint16(1))  // Image count
+	ico.Write([]byte{size, size, 0, 0})                 // Width, height, palette size, reserved
+	binary.Write(&ico, binary.LittleEndian, uint16(1))  // Color planes
+	binary.Write(&ico, binary.LittleEndian, uint16(32)) // Bits per pixel
+	binary.Write(&ico, binary.LittleEndian, uint32(pngData.Len()))
+	binary.Write(&ico, binary.LittleEndian, uint32(6+16)) // Image data follows the single directory entry
+	ico.Write(pngData.Bytes())
+	return ico.Bytes()
+}
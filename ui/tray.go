@@ -5,11 +5,15 @@ import (
 	"client/conn"
 	"client/logger"
 	"client/platform"
+	"client/version"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
 	"os/exec"
 	"runtime"
@@ -27,13 +31,27 @@ var triggerLoginChan = make(chan bool, 1)
 // Channel to cancel pending authentication timeouts
 var cancelAuthTimeoutChan = make(chan bool, 10)
 
+// How long the "Reset Settings" item waits for the confirming second click
+const resetConfirmWindow = 5 * time.Second
+
+// licenseURL is opened by About → License
+const licenseURL = "https://github.com/Vyx-Network/Vyx-Client/blob/main/LICENSE"
+
+// How long "Start Sharing" waits for the pre-flight connectivity test
+const connectivityTestTimeout = 30 * time.Second
+
 func SetupTray(websiteUrl string, icon []byte) {
 	// DEBUG MODE: Use localhost website for authentication
-	if config.GlobalConfig != nil && config.GlobalConfig.DebugMode {
+	if config.GetDebugMode() {
 		websiteUrl = "http://127.0.0.1:8080"
 		log.Printf("DEBUG MODE: Using localhost website: %s", websiteUrl)
 	}
 
+	// A broken icon leaves the tray invisible or unclickable on some platforms, with no error from systray
+	if err := checkTrayIcon(icon); err != nil {
+		logger.Error("Tray icon can't be used (%v), falling back to a generated icon", err)
+		icon = fallbackTrayIcon()
+	}
 	systray.SetTemplateIcon(icon, icon)
 	systray.SetTooltip("Vyx - Proxy Node Client")
 
@@ -47,8 +65,24 @@ func SetupTray(websiteUrl string, icon []byte) {
 	connsItem := systray.AddMenuItem("Active Connections: 0", "Number of active proxy connections")
 	connsItem.Disable()
 
+	reconnectsItem := systray.AddMenuItem("Reconnects: 0", "Reconnects this session and in total")
+	reconnectsItem.Disable()
+
+	encryptionItem := systray.AddMenuItem("Encryption: --", "Negotiated TLS version and cipher suite")
+	encryptionItem.Disable()
+	encryptionItem.Hide()
+
+	lastErrorItem := systray.AddMenuItem("Last Error: --", "Most recent connection error")
+	lastErrorItem.Disable()
+	lastErrorItem.Hide()
+
 	systray.AddSeparator()
 
+	// Logged-in account (non-clickable, hidden when logged out)
+	accountItem := systray.AddMenuItem("Account: --", "Account this node is linked to")
+	accountItem.Disable()
+	accountItem.Hide()
+
 	// Action items
 	loginItem := systray.AddMenuItem("Login", "Login with your account")
 	startItem := systray.AddMenuItem("Start Sharing", "Start sharing bandwidth and earning credits")
@@ -59,23 +93,41 @@ func SetupTray(websiteUrl string, icon []byte) {
 
 	// Settings menu
 	autoStartItem := systray.AddMenuItemCheckbox("Run at Startup", "Start Vyx automatically when computer starts", config.GetAutoStartEnabled())
+	telemetryItem := systray.AddMenuItemCheckbox("Share Anonymous Usage Stats", "Send anonymous stats (OS, version, uptime) to help improve Vyx - never destinations or account data", config.GetTelemetryEnabled())
+	resetItem := systray.AddMenuItem("Reset Settings", "Log out and restore default settings")
+	systray.AddSeparator()
+
+	// About submenu: version is shown inline so support can ask "what version are you on?"
+	aboutItem := systray.AddMenuItem("About Vyx", "Version, website and license")
+	aboutVersionItem := aboutItem.AddSubMenuItem("Version "+version.String(), "Client version and build")
+	aboutVersionItem.Disable()
+	aboutWebsiteItem := aboutItem.AddSubMenuItem("Website", websiteUrl)
+	aboutLicenseItem := aboutItem.AddSubMenuItem("License (MIT)", "Open the license")
 	systray.AddSeparator()
 
 	quitItem := systray.AddMenuItem("Quit", "Quit the whole app")
 
 	// Start status updater
-	go updateStatusDisplay(statusItem, uptimeItem, connsItem)
+	go updateStatusDisplay(statusItem, uptimeItem, connsItem, reconnectsItem, encryptionItem, lastErrorItem)
 
 	// Show/hide menu items based on login status and connection status
 	updateMenuVisibility := func() {
 		isLoggedIn := config.IsLoggedIn()
-		isSharing := conn.IsConnected()
+		// Derived from the connection state, so Stop stays visible while reconnecting/backing off
+		isSharing := conn.CurrentState().IsSharing()
 
 		if isLoggedIn {
 			loginItem.Hide()
 			dashboard.Show()
 			logout.Show()
 
+			if cfg := config.Current(); cfg != nil && cfg.Email != "" {
+				accountItem.SetTitle(fmt.Sprintf("Account: %s", cfg.Email))
+				accountItem.Show()
+			} else {
+				accountItem.Hide()
+			}
+
 			if isSharing {
 				startItem.Hide()
 				stopItem.Show()
@@ -84,6 +136,7 @@ func SetupTray(websiteUrl string, icon []byte) {
 				stopItem.Hide()
 			}
 		} else {
+			accountItem.Hide()
 			loginItem.Show()
 			startItem.Hide()
 			stopItem.Hide()
@@ -105,13 +158,16 @@ func SetupTray(websiteUrl string, icon []byte) {
 		}
 	}()
 
+	resetArmed := false
+	resetDisarmChan := make(chan bool, 1)
+
 	go func() {
 		for {
 			select {
 			case <-triggerLoginChan:
-				// External trigger for login (e.g., auto-login on first start)
+				// External trigger for login (first start or rejected token)
 				if !config.IsLoggedIn() {
-					log.Println("Auto-triggering login on first start...")
+					log.Println("Auto-triggering login...")
 					triggerLogin(websiteUrl, loginItem, startItem, stopItem, dashboard, logout, updateMenuVisibility)
 				}
 			case <-loginItem.ClickedCh:
@@ -126,9 +182,25 @@ func SetupTray(websiteUrl string, icon []byte) {
 				// Start sharing bandwidth
 				if config.IsLoggedIn() {
 					log.Println("Starting bandwidth sharing...")
-					conn.ReconnectQuic()
-					// Give it a moment to connect, then update UI
+					// Check reachability first so a broken setup fails loudly instead of looping silently
+					logger.GetStatus().UpdateStatus("Testing connection...")
 					go func() {
+						ctx, cancel := context.WithTimeout(context.Background(), connectivityTestTimeout)
+						defer cancel()
+						if err := conn.TestConnectivity(ctx); err != nil {
+							reason := err.Error()
+							var connErr *conn.ConnectivityError
+							if errors.As(err, &connErr) {
+								reason = connErr.Reason()
+							}
+							log.Printf("Not starting sharing: %v", err)
+							logger.GetStatus().RecordFailure(reason)
+							ShowNotification("Vyx - can't start sharing", reason)
+							return
+						}
+
+						conn.ReconnectQuic()
+						// Give it a moment to connect, then update UI
 						time.Sleep(500 * time.Millisecond)
 						updateMenuVisibility()
 					}()
@@ -138,8 +210,12 @@ func SetupTray(websiteUrl string, icon []byte) {
 			case <-stopItem.ClickedCh:
 				// Stop sharing bandwidth
 				log.Println("Stopping bandwidth sharing...")
+				wasSharing := conn.CurrentState().IsSharing()
 				conn.DisconnectQuic()
 				updateMenuVisibility()
+				if summary := logger.GetStatus().LastSessionSummary; wasSharing && summary != "" {
+					ShowNotification("Vyx - sharing stopped", summary)
+				}
 			case <-authSuccessChan:
 				// BUG FIX: Only update UI after successful authentication
 				log.Println("Authentication successful - updating UI and reconnecting...")
@@ -163,7 +239,15 @@ func SetupTray(websiteUrl string, icon []byte) {
 			case <-dashboard.ClickedCh:
 				err := open(websiteUrl + "/dashboard")
 				if err != nil {
-					log.Println("Failed to open browser:", err)
+					logger.Warn("Failed to open browser: %v", err)
+				}
+			case <-aboutWebsiteItem.ClickedCh:
+				if err := open(websiteUrl); err != nil {
+					logger.Warn("Failed to open browser: %v", err)
+				}
+			case <-aboutLicenseItem.ClickedCh:
+				if err := open(licenseURL); err != nil {
+					logger.Warn("Failed to open browser: %v", err)
 				}
 			case <-logout.ClickedCh:
 				// Disconnect QUIC connection first
@@ -171,12 +255,9 @@ func SetupTray(websiteUrl string, icon []byte) {
 				log.Println("Disconnected from server")
 
 				// Clear credentials
-				if config.GlobalConfig != nil {
-					config.GlobalConfig.APIToken = ""
-					config.GlobalConfig.UserID = ""
-					config.GlobalConfig.Email = ""
-					if err := config.SaveConfig(config.GlobalConfig); err != nil {
-						log.Println("Failed to save config:", err)
+				if config.Current() != nil {
+					if err := config.SetCredentials("", "", ""); err != nil {
+						logger.Warn("Failed to save config: %v", err)
 					}
 				}
 				log.Println("Logged out successfully")
@@ -219,7 +300,51 @@ func SetupTray(websiteUrl string, icon []byte) {
 						autoStartItem.Uncheck()
 					}
 				}
+			case <-telemetryItem.ClickedCh:
+				// Toggle anonymous telemetry preference (reporter picks it up on next tick)
+				newState := !config.GetTelemetryEnabled()
+				if err := config.SetTelemetryEnabled(newState); err != nil {
+					logger.Error("Failed to save telemetry preference: %v", err)
+				} else if newState {
+					log.Println("Anonymous usage stats enabled")
+					telemetryItem.Check()
+				} else {
+					log.Println("Anonymous usage stats disabled")
+					telemetryItem.Uncheck()
+				}
+			case <-resetItem.ClickedCh:
+				// Two-step confirmation: first click arms, second click within the window resets
+				if !resetArmed {
+					resetArmed = true
+					resetItem.SetTitle("Click again to confirm reset")
+					go func() {
+						time.Sleep(resetConfirmWindow)
+						resetDisarmChan <- true
+					}()
+					continue
+				}
+				resetArmed = false
+				resetItem.SetTitle("Reset Settings")
+
+				conn.DisconnectQuic()
+				if err := config.ResetConfig(); err != nil {
+					logger.Error("Failed to reset settings: %v", err)
+					ShowNotification("Vyx", "Failed to reset settings - see logs")
+				} else {
+					log.Println("Settings reset from tray")
+					autoStartItem.Check()
+					telemetryItem.Uncheck()
+					ShowNotification("Vyx", "Settings reset. Please log in again.")
+				}
+				updateMenuVisibility()
+			case <-resetDisarmChan:
+				if resetArmed {
+					resetArmed = false
+					resetItem.SetTitle("Reset Settings")
+				}
 			case <-quitItem.ClickedCh:
+				// Close the session cleanly so it gets a summary in the log
+				conn.DisconnectQuic()
 				systray.Quit()
 				return
 			}
@@ -241,191 +366,231 @@ func open(url string) error {
 		cmd = "xdg-open"
 	}
 	args = append(args, url)
-	return exec.Command(cmd, args...).Start()
-}
-
-func startAuthServer() string {
-	// Try up to 5 times to find an available port
-	var server *http.Server
-	var port string
-	maxRetries := 5
+	c := exec.Command(cmd, args...)
+	if err := c.Start(); err != nil {
+		return err
+	}
 
-	for i := 0; i < maxRetries; i++ {
-		port = fmt.Sprintf("%d", 50000+rand.Intn(10000))
-
-		mux := http.NewServeMux()
-		mux.HandleFunc("/auth-result", func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("Received auth callback: Method=%s, Origin=%s, RemoteAddr=%s",
-				r.Method, r.Header.Get("Origin"), r.RemoteAddr)
-
-			// Add CORS headers - restrict origins based on debug mode
-			origin := r.Header.Get("Origin")
-			var allowedOrigins []string
-
-			// In debug mode, allow localhost origins for development
-			if config.GlobalConfig != nil && config.GlobalConfig.DebugMode {
-				allowedOrigins = []string{
-					"http://localhost:3000",
-					"http://127.0.0.1:8080",
-					"http://localhost:8080",
-					"https://vyx.network",
-					"https://www.vyx.network",
-				}
-			} else {
-				// In production, only allow production origins
-				allowedOrigins = []string{
-					"https://vyx.network",
-					"https://www.vyx.network",
-				}
-			}
+	// Launchers like xdg-open report "no browser" via their exit code; catch quick failures
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", cmd, err)
+		}
+	case <-time.After(2 * time.Second):
+		// Still running - the browser is starting
+	}
+	return nil
+}
 
-			originAllowed := false
-			for _, allowedOrigin := range allowedOrigins {
-				if origin == allowedOrigin {
-					w.Header().Set("Access-Control-Allow-Origin", origin)
-					originAllowed = true
-					break
-				}
+func startAuthServer() (string, *http.Server, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth-result", func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("Received auth callback: Method=%s, Origin=%s, RemoteAddr=%s",
+			r.Method, r.Header.Get("Origin"), r.RemoteAddr)
+
+		// Add CORS headers - restrict origins based on debug mode
+		origin := r.Header.Get("Origin")
+		var allowedOrigins []string
+
+		// In debug mode, allow localhost origins for development
+		if config.GetDebugMode() {
+			allowedOrigins = []string{
+				"http://localhost:3000",
+				"http://127.0.0.1:8080",
+				"http://localhost:8080",
+				"https://vyx.network",
+				"https://www.vyx.network",
 			}
-
-			if !originAllowed && origin != "" {
-				log.Printf("WARNING: Rejected CORS origin: %s (not in allowed list)", origin)
+		} else {
+			// In production, only allow production origins
+			allowedOrigins = []string{
+				"https://vyx.network",
+				"https://www.vyx.network",
 			}
+		}
 
-			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Max-Age", "3600") // Cache preflight requests for 1 hour
-
-			// Security headers to protect against common web vulnerabilities
-			w.Header().Set("X-Content-Type-Options", "nosniff")
-			w.Header().Set("X-Frame-Options", "DENY")
-			w.Header().Set("X-XSS-Protection", "1; mode=block")
-			w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none';")
-
-			// Handle preflight OPTIONS request
-			if r.Method == "OPTIONS" {
-				log.Println("Handled CORS preflight request")
-				w.WriteHeader(http.StatusOK)
-				return
+		originAllowed := false
+		for _, allowedOrigin := range allowedOrigins {
+			if origin == allowedOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				originAllowed = true
+				break
 			}
+		}
 
-			if r.Method != "POST" {
-				log.Printf("ERROR: Invalid method %s (expected POST)", r.Method)
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-				return
-			}
+		if !originAllowed && origin != "" {
+			log.Printf("WARNING: Rejected CORS origin: %s (not in allowed list)", origin)
+		}
 
-			body, err := io.ReadAll(r.Body)
-			if err != nil {
-				log.Println("Failed to read auth response:", err)
-				http.Error(w, "Failed to read body", http.StatusBadRequest)
-				return
-			}
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Max-Age", "3600") // Cache preflight requests for 1 hour
 
-			var authData struct {
-				Token  string `json:"token"`
-				UserID string `json:"user_id"`
-				Email  string `json:"email"`
-			}
+		// Security headers to protect against common web vulnerabilities
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("X-XSS-Protection", "1; mode=block")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none';")
 
-			if err := json.Unmarshal(body, &authData); err != nil {
-				log.Println("Failed to parse auth response:", err)
-				log.Printf("Received body: %s", string(body))
-				http.Error(w, "Invalid JSON", http.StatusBadRequest)
-				return
-			}
+		// Handle preflight OPTIONS request
+		if r.Method == "OPTIONS" {
+			log.Println("Handled CORS preflight request")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
 
-			log.Printf("Received auth data - Token: %s..., UserID: %s, Email: %s",
-				authData.Token[:min(10, len(authData.Token))],
-				authData.UserID,
-				authData.Email)
+		if r.Method != "POST" {
+			log.Printf("ERROR: Invalid method %s (expected POST)", r.Method)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
 
-			// Save credentials to config
-			if config.GlobalConfig == nil {
-				config.GlobalConfig = &config.Config{
-					ServerURL: "api.vyx.network:8443",
-				}
-			}
-			config.GlobalConfig.APIToken = authData.Token
-			config.GlobalConfig.UserID = authData.UserID
-			config.GlobalConfig.Email = authData.Email
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.Warn("Failed to read auth response: %v", err)
+			http.Error(w, "Failed to read body", http.StatusBadRequest)
+			return
+		}
 
-			if err := config.SaveConfig(config.GlobalConfig); err != nil {
-				log.Println("Failed to save config:", err)
-				http.Error(w, "Failed to save config", http.StatusInternalServerError)
-				return
-			}
+		var authData struct {
+			Token  string `json:"token"`
+			UserID string `json:"user_id"`
+			Email  string `json:"email"`
+		}
 
-			log.Printf("Successfully authenticated as: %s", authData.Email)
-			log.Printf("Config saved. IsLoggedIn: %v", config.IsLoggedIn())
+		if err := json.Unmarshal(body, &authData); err != nil {
+			// SECURITY: the body carries the token, so only its size is logged
+			logger.Warn("Failed to parse auth response (%d bytes): %v", len(body), err)
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
 
-			// BUG FIX: Signal successful authentication to update UI
-			select {
-			case authSuccessChan <- true:
-				log.Println("Sent auth success signal to tray")
-			default:
-				log.Println("Auth success channel full, tray already notified")
-			}
+		log.Printf("Received auth data - Token: %s, UserID: %s, Email: %s",
+			config.RedactToken(authData.Token),
+			config.RedactPersonal(authData.UserID),
+			config.RedactPersonal(authData.Email))
 
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("OK"))
-		})
-
-		// MAC FIX: Explicitly bind to 127.0.0.1 to avoid firewall issues on macOS
-		server = &http.Server{
-			Addr:         "127.0.0.1:" + port,
-			Handler:      mux,
-			ReadTimeout:  30 * time.Second,
-			WriteTimeout: 30 * time.Second,
+		// Save credentials to config
+		if config.Current() == nil {
+			config.SetCurrent(&config.Config{
+				ServerURL: "api.vyx.network:8443",
+			})
 		}
 
-		// Test if we can bind to this port
-		log.Printf("Attempting to start auth server on 127.0.0.1:%s (attempt %d/%d)", port, i+1, maxRetries)
+		if err := config.SetCredentials(authData.Token, authData.UserID, authData.Email); errors.Is(err, config.ErrTokenNotPersisted) {
+			// Logged in for this run only - say so now rather than after the next restart
+			log.Printf("Warning: %v - the login will only last until Vyx restarts", err)
+			ShowNotification("Vyx login", "Logged in for this session only - the system keyring couldn't store your login. Unlock it and log in again to stay logged in.")
+		} else if err != nil {
+			logger.Warn("Failed to save config: %v", err)
+			http.Error(w, "Failed to save config", http.StatusInternalServerError)
+			return
+		}
 
-		// Start server in goroutine with error channel
-		errChan := make(chan error, 1)
-		go func() {
-			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				errChan <- err
-			}
-		}()
+		log.Printf("Successfully authenticated as: %s", config.RedactPersonal(authData.Email))
+		log.Printf("Config saved. IsLoggedIn: %v", config.IsLoggedIn())
 
-		// Wait a moment to see if server starts successfully
+		// BUG FIX: Signal successful authentication to update UI
 		select {
-		case err := <-errChan:
-			log.Printf("Failed to start server on port %s: %v", port, err)
-			if i < maxRetries-1 {
-				log.Println("Retrying with different port...")
-				continue
-			}
-			log.Printf("CRITICAL: Could not start auth server after %d attempts", maxRetries)
-			return ""
-		case <-time.After(100 * time.Millisecond):
-			// Server started successfully
-			log.Printf("✓ Auth server started successfully on 127.0.0.1:%s", port)
-			log.Printf("Ready to receive authentication callback from browser")
-			return port
+		case authSuccessChan <- true:
+			log.Println("Sent auth success signal to tray")
+		default:
+			log.Println("Auth success channel full, tray already notified")
 		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	// MAC FIX: Explicitly bind to 127.0.0.1 to avoid firewall issues on macOS
+	server := &http.Server{
+		Handler:      mux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
 	}
 
-	return port
+	// Bind synchronously so bind errors surface immediately and the server is
+	// accepting before the browser is opened
+	listener, err := listenAuthCallback()
+	if err != nil {
+		return "", nil, err
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Auth server stopped: %v", err)
+		}
+	}()
+
+	port := fmt.Sprintf("%d", listener.Addr().(*net.TCPAddr).Port)
+	log.Printf("✓ Auth server started successfully on 127.0.0.1:%s", port)
+	log.Printf("Ready to receive authentication callback from browser")
+	return port, server, nil
+}
+
+// shutdownAuthServer gracefully stops a login attempt's callback server and frees its port
+func shutdownAuthServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Auth server shutdown error: %v", err)
+		server.Close()
+		return
+	}
+	log.Println("Auth server stopped")
+}
+
+// listenAuthCallback binds the auth callback listener on 127.0.0.1
+// Tries a few random high ports first, then lets the OS pick a free one
+func listenAuthCallback() (net.Listener, error) {
+	maxRetries := 5
+
+	var lastErr error
+	for i := 0; i < maxRetries; i++ {
+		addr := fmt.Sprintf("127.0.0.1:%d", 50000+rand.Intn(10000))
+		log.Printf("Attempting to start auth server on %s (attempt %d/%d)", addr, i+1, maxRetries)
+
+		listener, err := net.Listen("tcp", addr)
+		if err == nil {
+			return listener, nil
+		}
+		logger.Warn("Failed to start server on %s: %v", addr, err)
+		lastErr = err
+	}
+
+	log.Println("Random ports unavailable, requesting any free port from the OS...")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("could not bind auth server on 127.0.0.1 (last random port error: %v): %w", lastErr, err)
+	}
+	return listener, nil
 }
 
 // updateStatusDisplay updates the tray menu status every 2 seconds
-func updateStatusDisplay(statusItem, uptimeItem, connsItem *systray.MenuItem) {
+func updateStatusDisplay(statusItem, uptimeItem, connsItem, reconnectsItem, encryptionItem, lastErrorItem *systray.MenuItem) {
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
 	for range ticker.C {
 		status := logger.GetStatus()
 
-		// Update status text
-		statusItem.SetTitle(fmt.Sprintf("Status: %s", status.Status))
+		// Update status text (a pending browser login shows its countdown instead)
+		if remaining := time.Until(status.LoginDeadline); remaining > 0 {
+			statusItem.SetTitle(fmt.Sprintf("Status: Waiting for browser login (%s left)", formatDuration(remaining)))
+		} else if remaining := time.Until(status.NextReconnectAt); remaining > 0 {
+			// Backing off after a failure: show the retry is coming rather than a bare failure
+			statusItem.SetTitle(fmt.Sprintf("Status: %s - reconnecting in %s", status.Status, formatDuration(remaining)))
+		} else {
+			statusItem.SetTitle(fmt.Sprintf("Status: %s", status.Status))
+		}
 
 		// Update uptime
 		uptime := "Not connected"
-		if !status.ConnectionUptime.IsZero() {
+		if conn.CurrentState() == conn.StateConnected && !status.ConnectionUptime.IsZero() {
 			duration := time.Since(status.ConnectionUptime)
 			uptime = formatDuration(duration)
 		}
@@ -434,15 +599,53 @@ func updateStatusDisplay(statusItem, uptimeItem, connsItem *systray.MenuItem) {
 		// Update connections
 		connsItem.SetTitle(fmt.Sprintf("Active Connections: %d", status.ActiveConns))
 
+		// Update reconnect counters (session and lifetime from the persisted history)
+		reconnectsItem.SetTitle(fmt.Sprintf("Reconnects: %d (%d total)", status.SessionReconnects, status.History.TotalReconnects))
+
+		// Update negotiated TLS parameters (hidden when not connected)
+		if status.TLSInfo != "" {
+			encryptionItem.SetTitle("Encryption: " + status.TLSInfo)
+			encryptionItem.Show()
+		} else {
+			encryptionItem.Hide()
+		}
+
+		// Update last error (hidden while connected without failures)
+		if status.LastError != "" {
+			lastErrorItem.SetTitle(fmt.Sprintf("Last Error: %s (x%d)", truncate(status.LastError, 60), status.ConsecutiveFailures))
+			lastErrorItem.Show()
+		} else if status.LoggingError != "" {
+			// No connection problem, but diagnostics are being lost - make that visible
+			lastErrorItem.SetTitle("Warning: " + truncate(status.LoggingError, 60))
+			lastErrorItem.Show()
+		} else {
+			lastErrorItem.Hide()
+		}
+
 		// Update tooltip with simple status (avoid duplicating menu items)
 		tooltipText := fmt.Sprintf("Vyx - %s", status.Status)
 		if status.ServerAddress != "" {
 			tooltipText = fmt.Sprintf("Vyx - %s (%s)", status.Status, status.ServerAddress)
 		}
+		if status.LastError != "" {
+			tooltipText += "\nLast error: " + truncate(status.LastError, 60)
+		}
+		if status.LoggingError != "" {
+			tooltipText += "\n" + status.LoggingError
+		}
 		systray.SetTooltip(tooltipText)
 	}
 }
 
+// truncate shortens s to at most n runes for menu display
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
 // formatDuration formats a duration into human-readable format
 func formatDuration(d time.Duration) string {
 	d = d.Round(time.Second)
@@ -468,12 +671,12 @@ func ShowNotification(title, message string) {
 	log.Printf("NOTIFICATION: %s - %s", title, message)
 }
 
-// TriggerAutoLogin triggers automatic browser login on first start
+// TriggerAutoLogin triggers automatic browser login (first start or after the token is rejected)
 // Should be called after tray is initialized
 func TriggerAutoLogin() {
 	select {
 	case triggerLoginChan <- true:
-		logger.Info("Triggered auto-login on first start")
+		logger.Info("Triggered automatic login")
 	default:
 		logger.Info("Auto-login already in progress")
 	}
@@ -482,11 +685,11 @@ func TriggerAutoLogin() {
 // triggerLogin handles the login flow (shared between manual click and auto-trigger)
 func triggerLogin(websiteUrl string, loginItem, startItem, stopItem, dashboard, logout *systray.MenuItem, updateMenuVisibility func()) {
 	// Start HTTP server to receive credentials
-	port := startAuthServer()
+	port, authServer, err := startAuthServer()
 
 	// Check if server started successfully
-	if port == "" {
-		log.Println("CRITICAL ERROR: Failed to start authentication server")
+	if err != nil {
+		log.Printf("CRITICAL ERROR: Failed to start authentication server: %v", err)
 		log.Println("Possible causes:")
 		log.Println("  1. Firewall is blocking local connections")
 		log.Println("  2. All attempted ports are already in use")
@@ -503,28 +706,36 @@ func triggerLogin(websiteUrl string, loginItem, startItem, stopItem, dashboard,
 	log.Printf("Opening browser for authentication on port %s...", port)
 	log.Printf("Auth URL: %s", authURL)
 
-	err := open(authURL)
+	err = open(authURL)
 	if err != nil {
 		log.Printf("ERROR: Failed to open browser: %v", err)
-		log.Printf("Please manually open this URL in your browser:")
+		log.Printf("Please manually open this URL in a browser on this computer:")
 		log.Printf("  %s", authURL)
+		log.Println("No browser here? Quit Vyx and run `vyx-client login` in a terminal to log in with email and password")
+		ShowNotification("Vyx login", "Couldn't open a browser. Open "+authURL+" to log in, or run `vyx-client login`.")
 	} else {
 		log.Println("Browser opened successfully - waiting for authentication...")
 	}
 
-	// Start timeout watcher (30 seconds for security)
+	// Start timeout watcher (configurable, long enough for 2FA in the browser)
+	authTimeout := config.GetAuthTimeout()
+	logger.GetStatus().LoginDeadline = time.Now().Add(authTimeout)
 	go func() {
-		timer := time.NewTimer(30 * time.Second)
+		timer := time.NewTimer(authTimeout)
 		defer timer.Stop()
 
+		// The callback server is only needed for this attempt - don't leak it either way
+		defer shutdownAuthServer(authServer)
+		defer func() { logger.GetStatus().LoginDeadline = time.Time{} }()
+
 		select {
 		case <-cancelAuthTimeoutChan:
 			// Auth succeeded, timeout cancelled
 			log.Println("Authentication timeout cancelled - login successful")
 			return
 		case <-timer.C:
-			log.Println("WARNING: Authentication timeout (30 seconds) - no response from browser")
-			log.Println("Please try again or check the logs for errors")
+			log.Printf("WARNING: Authentication timeout (%v) - no response from browser", authTimeout)
+			log.Println("Please try again or raise auth_timeout_seconds in config if login needs more time")
 			// UI stays in "Connect" state, user can try again
 		}
 	}()
@@ -0,0 +1,27 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Build information, overridable at build time:
+//
+//	go build -ldflags="-X client/version.Version=v0.2.0 -X client/version.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "v0.1.1" // Semver format (must start with 'v')
+	Commit    = ""       // Git commit the binary was built from
+	BuildDate = ""       // Build timestamp (RFC 3339)
+)
+
+// String returns a human-readable build description, e.g. "v0.1.1 (abc1234, windows/amd64)"
+func String() string {
+	details := runtime.GOOS + "/" + runtime.GOARCH
+	if Commit != "" {
+		details = Commit + ", " + details
+	}
+	if BuildDate != "" {
+		details += ", built " + BuildDate
+	}
+	return fmt.Sprintf("%s (%s)", Version, details)
+}